@@ -7,6 +7,24 @@ import (
 	"time"
 )
 
+// Output writes result to writer using the named format ("text", "json",
+// "sarif", or "junit"), matching the values accepted by
+// config.Config.DefaultOutputFormat.
+func Output(format string, result *AnalysisResult, writer io.Writer) error {
+	switch format {
+	case "json":
+		return OutputJSON(result, writer)
+	case "sarif":
+		return OutputSARIF(result, writer)
+	case "junit":
+		return OutputJUnit(result, writer)
+	case "text", "":
+		return OutputText(result, writer)
+	default:
+		return fmt.Errorf("unknown output format: %q", format)
+	}
+}
+
 // AnalysisResult represents the result of a Docker image analysis
 type AnalysisResult struct {
 	ImagePath     string          `json:"imagePath"`
@@ -16,8 +34,10 @@ type AnalysisResult struct {
 	SizeInfo      *SizeInfo       `json:"sizeInfo,omitempty"`
 }
 
-// AnalyzeImage analyzes a Docker image
-func AnalyzeImage(imagePath string, verbose bool) *AnalysisResult {
+// AnalyzeImage analyzes a Docker image. By default it walks the real OS
+// filesystem; pass WithFS to analyze an afero.Fs instead, e.g. a MemMapFs
+// mounting an unpacked image layer without touching local storage.
+func AnalyzeImage(imagePath string, verbose bool, opts ...Option) *AnalysisResult {
 	result := &AnalysisResult{
 		ImagePath:  imagePath,
 		AnalyzedAt: time.Now(),
@@ -25,7 +45,7 @@ func AnalyzeImage(imagePath string, verbose bool) *AnalysisResult {
 
 	// Analyze directory structure
 	fmt.Println("Analyzing directory structure...")
-	dirInfo, err := AnalyzeDirectory(imagePath, verbose)
+	dirInfo, err := AnalyzeDirectory(imagePath, verbose, opts...)
 	if err != nil {
 		fmt.Printf("Error analyzing directory structure: %v\n", err)
 	} else {
@@ -34,7 +54,7 @@ func AnalyzeImage(imagePath string, verbose bool) *AnalysisResult {
 
 	// Analyze security
 	fmt.Println("Analyzing security...")
-	securityInfo, err := AnalyzeSecurity(imagePath)
+	securityInfo, err := AnalyzeSecurity(imagePath, opts...)
 	if err != nil {
 		fmt.Printf("Error analyzing security: %v\n", err)
 	} else {
@@ -43,7 +63,7 @@ func AnalyzeImage(imagePath string, verbose bool) *AnalysisResult {
 
 	// Analyze size
 	fmt.Println("Analyzing size...")
-	sizeInfo, err := AnalyzeSize(imagePath)
+	sizeInfo, err := AnalyzeSize(imagePath, opts...)
 	if err != nil {
 		fmt.Printf("Error analyzing size: %v\n", err)
 	} else {
@@ -0,0 +1,127 @@
+package analyzer
+
+import (
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/spf13/afero"
+	"github.com/ulikunitz/xz"
+)
+
+// layerCompression identifies the compression format of a layer blob.
+type layerCompression int
+
+const (
+	compressionNone layerCompression = iota
+	compressionGzip
+	compressionZstd
+	compressionXZ
+	compressionBzip2
+)
+
+// compressionMagic lists the magic bytes scallop recognizes, in the same
+// order moby's DetectCompression checks them.
+var compressionMagic = []struct {
+	format layerCompression
+	magic  []byte
+}{
+	{compressionGzip, []byte{0x1f, 0x8b}},
+	{compressionZstd, []byte{0x28, 0xb5, 0x2f, 0xfd}},
+	{compressionXZ, []byte{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00}},
+	{compressionBzip2, []byte{0x42, 0x5a, 0x68}},
+}
+
+// detectLayerCompression sniffs the leading bytes of the file at path to
+// determine its compression format, defaulting to compressionNone (a plain
+// tar) when no known magic number matches.
+func detectLayerCompression(fs afero.Fs, path string) (layerCompression, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return compressionNone, err
+	}
+	defer f.Close()
+
+	header := make([]byte, 6)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return compressionNone, err
+	}
+	header = header[:n]
+
+	for _, m := range compressionMagic {
+		if bytes.HasPrefix(header, m.magic) {
+			return m.format, nil
+		}
+	}
+	return compressionNone, nil
+}
+
+// openLayerStream opens the layer blob at path and wraps it in the
+// appropriate decompressor, detected from its magic bytes. The returned
+// close func releases both the decompressor and the underlying file.
+func openLayerStream(fs afero.Fs, path string) (io.Reader, func() error, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	format, err := detectLayerCompression(fs, path)
+	if err != nil {
+		f.Close()
+		return nil, nil, err
+	}
+
+	switch format {
+	case compressionGzip:
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("failed to open gzip layer: %v", err)
+		}
+		return gz, func() error { gz.Close(); return f.Close() }, nil
+	case compressionZstd:
+		zr, err := zstd.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("failed to open zstd layer: %v", err)
+		}
+		return zr, func() error { zr.Close(); return f.Close() }, nil
+	case compressionXZ:
+		xr, err := xz.NewReader(f)
+		if err != nil {
+			f.Close()
+			return nil, nil, fmt.Errorf("failed to open xz layer: %v", err)
+		}
+		return xr, f.Close, nil
+	case compressionBzip2:
+		return bzip2.NewReader(f), f.Close, nil
+	default:
+		return f, f.Close, nil
+	}
+}
+
+// layerDiffStats decompresses the layer blob at path (if compressed) and
+// returns its uncompressed size along with its diffID: the "sha256:<hex>"
+// digest of the uncompressed stream, as recorded in an image config's
+// rootfs.diff_ids.
+func layerDiffStats(fs afero.Fs, path string) (uncompressedSize int64, diffID string, err error) {
+	r, closeStream, err := openLayerStream(fs, path)
+	if err != nil {
+		return 0, "", err
+	}
+	defer closeStream()
+
+	hasher := sha256.New()
+	n, err := io.Copy(hasher, r)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to read layer stream: %v", err)
+	}
+
+	return n, "sha256:" + hex.EncodeToString(hasher.Sum(nil)), nil
+}
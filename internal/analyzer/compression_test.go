@@ -0,0 +1,171 @@
+package analyzer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestGetBlobLayerSizesDecompressesAndVerifiesDiffIDs(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "blob-layer-sizes-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	blobsDir := filepath.Join(tempDir, "blobs", "sha256")
+	if err := os.MkdirAll(blobsDir, 0755); err != nil {
+		t.Fatalf("Failed to create blobs directory: %v", err)
+	}
+
+	plain := []byte("uncompressed layer content")
+	uncompressedHash := sha256.Sum256(plain)
+	diffID := "sha256:" + hex.EncodeToString(uncompressedHash[:])
+
+	var gzBuf bytes.Buffer
+	gz := gzip.NewWriter(&gzBuf)
+	if _, err := gz.Write(plain); err != nil {
+		t.Fatalf("Failed to gzip layer content: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+
+	layerHash := sha256.Sum256(gzBuf.Bytes())
+	layerDigest := "sha256:" + hex.EncodeToString(layerHash[:])
+	layerHex := hex.EncodeToString(layerHash[:])
+	if err := os.WriteFile(filepath.Join(blobsDir, layerHex), gzBuf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write gzipped layer blob: %v", err)
+	}
+
+	config := struct {
+		RootFS struct {
+			DiffIDs []string `json:"diff_ids"`
+		} `json:"rootfs"`
+	}{}
+	config.RootFS.DiffIDs = []string{diffID}
+	configData, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Failed to marshal image config: %v", err)
+	}
+	configHash := sha256.Sum256(configData)
+	configHex := hex.EncodeToString(configHash[:])
+	if err := os.WriteFile(filepath.Join(blobsDir, configHex), configData, 0644); err != nil {
+		t.Fatalf("Failed to write image config blob: %v", err)
+	}
+
+	manifest := struct {
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+		Layers []struct {
+			Digest string `json:"digest"`
+		} `json:"layers"`
+	}{}
+	manifest.Config.Digest = "sha256:" + configHex
+	manifest.Layers = []struct {
+		Digest string `json:"digest"`
+	}{{Digest: layerDigest}}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("Failed to marshal image manifest: %v", err)
+	}
+	manifestHash := sha256.Sum256(manifestData)
+	manifestHex := hex.EncodeToString(manifestHash[:])
+	if err := os.WriteFile(filepath.Join(blobsDir, manifestHex), manifestData, 0644); err != nil {
+		t.Fatalf("Failed to write image manifest blob: %v", err)
+	}
+
+	index := struct {
+		Manifests []struct {
+			Digest string `json:"digest"`
+		} `json:"manifests"`
+	}{}
+	index.Manifests = []struct {
+		Digest string `json:"digest"`
+	}{{Digest: "sha256:" + manifestHex}}
+	indexData, err := json.Marshal(index)
+	if err != nil {
+		t.Fatalf("Failed to marshal index.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "index.json"), indexData, 0644); err != nil {
+		t.Fatalf("Failed to write index.json: %v", err)
+	}
+
+	fs := afero.NewOsFs()
+	layerSizes, integrityError, err := getBlobLayerSizes(fs, tempDir)
+	if err != nil {
+		t.Fatalf("getBlobLayerSizes failed: %v", err)
+	}
+	if integrityError != "" {
+		t.Errorf("integrityError = %q, expected empty (diffID should match)", integrityError)
+	}
+
+	if len(layerSizes) != 1 {
+		t.Fatalf("len(layerSizes) = %d, expected 1", len(layerSizes))
+	}
+	if layerSizes[0].ID != layerDigest {
+		t.Errorf("layerSizes[0].ID = %q, expected %q", layerSizes[0].ID, layerDigest)
+	}
+	if layerSizes[0].UncompressedSize != int64(len(plain)) {
+		t.Errorf("layerSizes[0].UncompressedSize = %d, expected %d", layerSizes[0].UncompressedSize, len(plain))
+	}
+	if layerSizes[0].CompressedSize != int64(gzBuf.Len()) {
+		t.Errorf("layerSizes[0].CompressedSize = %d, expected %d", layerSizes[0].CompressedSize, gzBuf.Len())
+	}
+
+	// Corrupt the config's expected diffID and confirm the mismatch surfaces.
+	config.RootFS.DiffIDs = []string{"sha256:0000000000000000000000000000000000000000000000000000000000000000"}
+	corruptData, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Failed to marshal corrupted config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(blobsDir, configHex), corruptData, 0644); err != nil {
+		t.Fatalf("Failed to overwrite image config blob: %v", err)
+	}
+
+	_, integrityError, err = getBlobLayerSizes(fs, tempDir)
+	if err != nil {
+		t.Fatalf("getBlobLayerSizes failed: %v", err)
+	}
+	if integrityError == "" {
+		t.Errorf("integrityError is empty, expected a diffID mismatch to be reported")
+	}
+}
+
+func TestDetectLayerCompression(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "detect-compression-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	fs := afero.NewOsFs()
+
+	plainPath := filepath.Join(tempDir, "plain.tar")
+	if err := os.WriteFile(plainPath, []byte("not compressed"), 0644); err != nil {
+		t.Fatalf("Failed to write plain file: %v", err)
+	}
+	if format, err := detectLayerCompression(fs, plainPath); err != nil || format != compressionNone {
+		t.Errorf("detectLayerCompression(plain) = (%v, %v), expected (compressionNone, nil)", format, err)
+	}
+
+	gzPath := filepath.Join(tempDir, "layer.tar.gz")
+	var gzBuf bytes.Buffer
+	gz := gzip.NewWriter(&gzBuf)
+	gz.Write([]byte("compressed content"))
+	gz.Close()
+	if err := os.WriteFile(gzPath, gzBuf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write gzip file: %v", err)
+	}
+	if format, err := detectLayerCompression(fs, gzPath); err != nil || format != compressionGzip {
+		t.Errorf("detectLayerCompression(gzip) = (%v, %v), expected (compressionGzip, nil)", format, err)
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"path/filepath"
 	"sort"
 	"strings"
+
+	"github.com/spf13/afero"
 )
 
 // DirectoryInfo represents information about a directory
@@ -18,15 +20,20 @@ type DirectoryInfo struct {
 	Dirs      []string       `json:"dirs,omitempty"`
 }
 
-// AnalyzeDirectory analyzes the directory structure of a Docker image
-func AnalyzeDirectory(imagePath string, verbose bool) (*DirectoryInfo, error) {
+// AnalyzeDirectory analyzes the directory structure of a Docker image.
+// By default it walks the real OS filesystem; pass WithFS to analyze an
+// afero.Fs instead (e.g. a MemMapFs in tests or a BasePathFs for chrooted
+// analysis).
+func AnalyzeDirectory(imagePath string, verbose bool, opts ...Option) (*DirectoryInfo, error) {
+	o := newOptions(opts...)
+
 	info := &DirectoryInfo{
 		Path:      imagePath,
 		FileTypes: make(map[string]int),
 	}
 
 	// Walk the directory tree
-	err := filepath.Walk(imagePath, func(path string, fileInfo os.FileInfo, err error) error {
+	err := afero.Walk(o.fs, imagePath, func(path string, fileInfo os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -77,69 +84,33 @@ func AnalyzeDirectory(imagePath string, verbose bool) (*DirectoryInfo, error) {
 	return info, err
 }
 
-// GetTopDirectories returns the top N directories by size
-func GetTopDirectories(imagePath string, count int) ([]DirectoryInfo, error) {
-	var dirs []DirectoryInfo
-
-	// Get all directories in the image
-	err := filepath.Walk(imagePath, func(path string, fileInfo os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip the root directory and non-directories
-		if path == imagePath || !fileInfo.IsDir() {
-			return nil
-		}
-
-		// Get the directory size
-		size, err := getDirSize(path)
-		if err != nil {
-			return err
-		}
-
-		// Get the relative path
-		relPath, err := filepath.Rel(imagePath, path)
-		if err != nil {
-			return err
-		}
-
-		// Add the directory to the list
-		dirs = append(dirs, DirectoryInfo{
-			Path: relPath,
-			Size: size,
-		})
-
-		return nil
-	})
+// GetTopDirectories returns the top N directories by size, at any depth
+// under imagePath. It delegates to walkSize for a single pass over the
+// tree, rather than independently re-walking each directory's subtree to
+// compute its size.
+func GetTopDirectories(imagePath string, count int, opts ...Option) ([]DirectoryInfo, error) {
+	o := newOptions(opts...)
 
+	result, err := walkSize(o.fs, imagePath, 0, false)
 	if err != nil {
 		return nil, err
 	}
 
-	// Sort the directories by size in descending order
-	sort.Slice(dirs, func(i, j int) bool {
-		return dirs[i].Size > dirs[j].Size
-	})
-
-	// Return the top N directories
-	if count > 0 && count < len(dirs) {
-		return dirs[:count], nil
-	}
-	return dirs, nil
+	// The root directory itself (".") is excluded from result.dirSizes'
+	// conversion to match GetTopDirectories' historical behavior of only
+	// reporting subdirectories of the walked path; topDirsBySize (shared
+	// with AnalyzeSizeStreaming) doesn't special-case "." since a streamed
+	// tar has no entry for the image root to begin with.
+	delete(result.dirSizes, ".")
+	return topDirsBySize(result.dirSizes, count), nil
 }
 
-// getDirSize returns the total size of all files in a directory in bytes
-func getDirSize(path string) (int64, error) {
-	var size int64
-	err := filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-		if !info.IsDir() {
-			size += info.Size()
-		}
-		return nil
-	})
-	return size, err
+// getDirSize returns the total size of all files in a directory in bytes.
+// It delegates to walkSize.
+func getDirSize(fs afero.Fs, path string) (int64, error) {
+	result, err := walkSize(fs, path, 0, false)
+	if err != nil {
+		return 0, err
+	}
+	return result.totalSize, nil
 }
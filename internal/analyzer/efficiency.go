@@ -0,0 +1,235 @@
+package analyzer
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/kanywst/scallop/internal/docker"
+	"github.com/spf13/afero"
+)
+
+// EfficiencyWrite records a single write to a path by one layer: either a
+// regular file write (IsWhiteout false, Size the file's size) or a
+// whiteout/opaque marker (IsWhiteout true) that removes whatever was
+// written to this path by earlier layers.
+type EfficiencyWrite struct {
+	LayerIndex int    `json:"layerIndex"`
+	LayerID    string `json:"layerId"`
+	Size       int64  `json:"size"`
+	IsWhiteout bool   `json:"isWhiteout"`
+}
+
+// LayerEfficiency summarizes one layer's contribution to the final image:
+// TotalBytes is everything the layer wrote, KeptBytes is the subset that
+// survives (i.e. isn't later overwritten or whited out), and Score is
+// KeptBytes/TotalBytes. AddedBytes/ModifiedBytes/DeletedBytes break
+// TotalBytes down by what the layer did to each path relative to earlier
+// layers: wrote it for the first time, overwrote an earlier layer's write,
+// or whited it out.
+type LayerEfficiency struct {
+	LayerIndex    int     `json:"layerIndex"`
+	LayerID       string  `json:"layerId"`
+	TotalBytes    int64   `json:"totalBytes"`
+	KeptBytes     int64   `json:"keptBytes"`
+	AddedBytes    int64   `json:"addedBytes"`
+	ModifiedBytes int64   `json:"modifiedBytes"`
+	DeletedBytes  int64   `json:"deletedBytes"`
+	Score         float64 `json:"score"`
+}
+
+// EfficiencyReport is the result of AnalyzeEfficiency: a dive-style
+// breakdown of wasted space across an image's layers.
+type EfficiencyReport struct {
+	// WastedBytes is the sum of sizes of every write that was later
+	// overwritten by a subsequent write to the same path before the image
+	// was finalized. It does not include DeletedBytes: a write later
+	// removed by a whiteout occupied space in its own layer, but wasn't
+	// superseded by another write, so it is tracked separately.
+	WastedBytes int64 `json:"wastedBytes"`
+	// DeletedBytes is the sum of sizes of every write later removed by a
+	// whiteout or opaque-directory marker rather than overwritten.
+	DeletedBytes int64 `json:"deletedBytes"`
+	// RawSize is the sum of every layer's TotalBytes: the on-disk cost of
+	// every write across every layer, with no cross-layer deduplication.
+	RawSize int64 `json:"rawSize"`
+	// EffectiveSize is the sum of every layer's KeptBytes: the size of the
+	// merged rootfs an image actually produces once later layers'
+	// overwrites and whiteouts are applied.
+	EffectiveSize int64 `json:"effectiveSize"`
+	// LayerScores holds one entry per layer, in manifest order.
+	LayerScores []LayerEfficiency `json:"layerScores"`
+	// Writes maps each path to every write it received, across all layers,
+	// in layer order.
+	Writes map[string][]EfficiencyWrite `json:"writes,omitempty"`
+}
+
+// AnalyzeEfficiency streams through each layer tarball of the image at
+// imagePath, in manifest order, and reports cross-layer wasted space: files
+// overwritten by a later layer, and files/directories removed by whiteout
+// markers (".wh.<name>", ".wh..wh..opq"). It uses docker.ReadImageLayout to
+// enumerate layers, so it works against an OCI Image Layout, a Docker v1.2
+// manifest.json, or the legacy "<layer-id>/layer.tar" tree, without
+// requiring the layers to be pre-extracted.
+func AnalyzeEfficiency(imagePath string, opts ...Option) (*EfficiencyReport, error) {
+	o := newOptions(opts...)
+
+	layers, err := docker.ReadImageLayout(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	writes := make(map[string][]EfficiencyWrite)
+	totalBytes := make([]int64, len(layers))
+
+	for idx, layer := range layers {
+		err := walkLayerTar(o.fs, layer.Path, func(path string, size int64, isWhiteout bool) {
+			if isWhiteout {
+				applyWhiteout(writes, path, idx, layer.Digest)
+				return
+			}
+			totalBytes[idx] += size
+			writes[path] = append(writes[path], EfficiencyWrite{
+				LayerIndex: idx,
+				LayerID:    layer.Digest,
+				Size:       size,
+			})
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read layer %s: %v", layer.Digest, err)
+		}
+	}
+
+	report := &EfficiencyReport{Writes: writes}
+	keptBytes := make([]int64, len(layers))
+	addedBytes := make([]int64, len(layers))
+	modifiedBytes := make([]int64, len(layers))
+	deletedBytes := make([]int64, len(layers))
+
+	for _, entries := range writes {
+		existsBefore := false
+		var prevSize int64
+
+		for i, w := range entries {
+			if w.IsWhiteout {
+				if existsBefore {
+					deletedBytes[w.LayerIndex] += prevSize
+					report.DeletedBytes += prevSize
+				}
+				existsBefore = false
+				prevSize = 0
+				continue
+			}
+
+			if existsBefore {
+				// This write supersedes the previous one, so the previous
+				// write's bytes never make it into the final image.
+				report.WastedBytes += prevSize
+				modifiedBytes[w.LayerIndex] += w.Size
+			} else {
+				addedBytes[w.LayerIndex] += w.Size
+			}
+
+			if i == len(entries)-1 {
+				keptBytes[w.LayerIndex] += w.Size
+			}
+
+			existsBefore = true
+			prevSize = w.Size
+		}
+	}
+
+	report.LayerScores = make([]LayerEfficiency, len(layers))
+	for i, layer := range layers {
+		var score float64
+		if totalBytes[i] > 0 {
+			score = float64(keptBytes[i]) / float64(totalBytes[i])
+		}
+		report.RawSize += totalBytes[i]
+		report.EffectiveSize += keptBytes[i]
+		report.LayerScores[i] = LayerEfficiency{
+			LayerIndex:    i,
+			LayerID:       layer.Digest,
+			TotalBytes:    totalBytes[i],
+			KeptBytes:     keptBytes[i],
+			AddedBytes:    addedBytes[i],
+			ModifiedBytes: modifiedBytes[i],
+			DeletedBytes:  deletedBytes[i],
+			Score:         score,
+		}
+	}
+
+	return report, nil
+}
+
+// applyWhiteout records that layerIdx removed path (a plain whiteout) or
+// everything under the directory path (an opaque marker) by appending a
+// terminal whiteout write to every affected path already seen.
+func applyWhiteout(writes map[string][]EfficiencyWrite, path string, layerIdx int, layerID string) {
+	prefix := path + string(filepath.Separator)
+
+	for p, entries := range writes {
+		if p != path && !strings.HasPrefix(p, prefix) {
+			continue
+		}
+		if last := entries[len(entries)-1]; last.IsWhiteout {
+			continue
+		}
+		writes[p] = append(entries, EfficiencyWrite{
+			LayerIndex: layerIdx,
+			LayerID:    layerID,
+			IsWhiteout: true,
+		})
+	}
+
+	// Record the whiteout itself even if nothing currently exists at path,
+	// so a whiteout for a file only present in a not-yet-analyzed layer (or
+	// outside this image entirely) is still visible in the report.
+	if _, ok := writes[path]; !ok {
+		writes[path] = []EfficiencyWrite{{LayerIndex: layerIdx, LayerID: layerID, IsWhiteout: true}}
+	}
+}
+
+// walkLayerTar streams the (optionally compressed) layer tarball at
+// layerPath, invoking visit for every regular file write and every
+// whiteout/opaque marker it contains. For a whiteout, path is the path it
+// removes (not the ".wh."-prefixed tar entry name).
+func walkLayerTar(fs afero.Fs, layerPath string, visit func(path string, size int64, isWhiteout bool)) error {
+	r, closeStream, err := openLayerStream(fs, layerPath)
+	if err != nil {
+		return err
+	}
+	defer closeStream()
+
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading layer tarball: %v", err)
+		}
+
+		name := filepath.Clean(header.Name)
+		dir := filepath.Dir(name)
+		base := filepath.Base(name)
+
+		if base == ".wh..wh..opq" {
+			visit(dir, 0, true)
+			continue
+		}
+		if strings.HasPrefix(base, ".wh.") {
+			visit(filepath.Join(dir, strings.TrimPrefix(base, ".wh.")), 0, true)
+			continue
+		}
+
+		if header.Typeflag == tar.TypeReg {
+			visit(name, header.Size, false)
+		}
+	}
+
+	return nil
+}
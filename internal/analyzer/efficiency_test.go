@@ -0,0 +1,100 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzeEfficiency(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "efficiency-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Layer 1 adds two files.
+	layer1Dir := filepath.Join(tempDir, "layer1")
+	if err := os.MkdirAll(layer1Dir, 0755); err != nil {
+		t.Fatalf("Failed to create layer1 directory: %v", err)
+	}
+	writeLayerTar(t, filepath.Join(layer1Dir, "layer.tar"), map[string]string{
+		"keep.txt":      "kept across layers",
+		"overwrite.txt": "twelve bytes",
+	})
+
+	// Layer 2 overwrites overwrite.txt with bigger content and whites out keep.txt.
+	layer2Dir := filepath.Join(tempDir, "layer2")
+	if err := os.MkdirAll(layer2Dir, 0755); err != nil {
+		t.Fatalf("Failed to create layer2 directory: %v", err)
+	}
+	writeLayerTar(t, filepath.Join(layer2Dir, "layer.tar"), map[string]string{
+		"overwrite.txt": "a much longer replacement value",
+		".wh.keep.txt":  "",
+	})
+
+	report, err := AnalyzeEfficiency(tempDir)
+	if err != nil {
+		t.Fatalf("AnalyzeEfficiency failed: %v", err)
+	}
+
+	wantWasted := int64(len("twelve bytes"))
+	if report.WastedBytes != wantWasted {
+		t.Errorf("WastedBytes = %d, expected %d", report.WastedBytes, wantWasted)
+	}
+
+	wantDeleted := int64(len("kept across layers"))
+	if report.DeletedBytes != wantDeleted {
+		t.Errorf("DeletedBytes = %d, expected %d", report.DeletedBytes, wantDeleted)
+	}
+
+	wantRaw := int64(len("kept across layers") + len("twelve bytes") + len("a much longer replacement value"))
+	if report.RawSize != wantRaw {
+		t.Errorf("RawSize = %d, expected %d", report.RawSize, wantRaw)
+	}
+
+	wantEffective := int64(len("a much longer replacement value"))
+	if report.EffectiveSize != wantEffective {
+		t.Errorf("EffectiveSize = %d, expected %d", report.EffectiveSize, wantEffective)
+	}
+
+	keepEntries, ok := report.Writes["keep.txt"]
+	if !ok {
+		t.Fatalf("Writes[%q] not found", "keep.txt")
+	}
+	if last := keepEntries[len(keepEntries)-1]; !last.IsWhiteout {
+		t.Errorf("keep.txt's last write should be a whiteout")
+	}
+
+	if len(report.LayerScores) != 2 {
+		t.Fatalf("len(LayerScores) = %d, expected 2", len(report.LayerScores))
+	}
+
+	layer2Score := report.LayerScores[1]
+	wantLayer2Kept := int64(len("a much longer replacement value"))
+	if layer2Score.KeptBytes != wantLayer2Kept {
+		t.Errorf("LayerScores[1].KeptBytes = %d, expected %d", layer2Score.KeptBytes, wantLayer2Kept)
+	}
+	if layer2Score.Score <= 0 || layer2Score.Score > 1 {
+		t.Errorf("LayerScores[1].Score = %f, expected value in (0, 1]", layer2Score.Score)
+	}
+
+	// Layer 2 modified overwrite.txt (a later write to a path layer 1 already
+	// wrote) and deleted keep.txt (whited it out).
+	if layer2Score.ModifiedBytes != wantLayer2Kept {
+		t.Errorf("LayerScores[1].ModifiedBytes = %d, expected %d", layer2Score.ModifiedBytes, wantLayer2Kept)
+	}
+	if layer2Score.DeletedBytes != wantDeleted {
+		t.Errorf("LayerScores[1].DeletedBytes = %d, expected %d", layer2Score.DeletedBytes, wantDeleted)
+	}
+
+	// Layer 1 only added new paths; it never modified or deleted anything.
+	layer1Score := report.LayerScores[0]
+	wantLayer1Added := int64(len("kept across layers") + len("twelve bytes"))
+	if layer1Score.AddedBytes != wantLayer1Added {
+		t.Errorf("LayerScores[0].AddedBytes = %d, expected %d", layer1Score.AddedBytes, wantLayer1Added)
+	}
+	if layer1Score.ModifiedBytes != 0 || layer1Score.DeletedBytes != 0 {
+		t.Errorf("LayerScores[0] ModifiedBytes/DeletedBytes = %d/%d, expected 0/0", layer1Score.ModifiedBytes, layer1Score.DeletedBytes)
+	}
+}
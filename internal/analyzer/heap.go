@@ -0,0 +1,62 @@
+package analyzer
+
+import "container/heap"
+
+// fileSizeHeap is a min-heap of FileSize ordered by Size, used to keep the
+// top-K largest files seen so far in bounded memory.
+type fileSizeHeap []FileSize
+
+func (h fileSizeHeap) Len() int            { return len(h) }
+func (h fileSizeHeap) Less(i, j int) bool  { return h[i].Size < h[j].Size }
+func (h fileSizeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *fileSizeHeap) Push(x interface{}) { *h = append(*h, x.(FileSize)) }
+func (h *fileSizeHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
+
+// topKFiles tracks the K largest FileSize values observed, evicting the
+// smallest once the bound is exceeded so memory stays O(K) regardless of how
+// many files are fed in.
+type topKFiles struct {
+	k int
+	h fileSizeHeap
+}
+
+func newTopKFiles(k int) *topKFiles {
+	return &topKFiles{k: k}
+}
+
+func (t *topKFiles) Add(f FileSize) {
+	if t.k <= 0 {
+		heap.Push(&t.h, f)
+		return
+	}
+	if t.h.Len() < t.k {
+		heap.Push(&t.h, f)
+		return
+	}
+	if t.h.Len() > 0 && f.Size > t.h[0].Size {
+		heap.Pop(&t.h)
+		heap.Push(&t.h, f)
+	}
+}
+
+// Sorted returns the tracked files sorted by descending size.
+func (t *topKFiles) Sorted() []FileSize {
+	items := make(fileSizeHeap, len(t.h))
+	copy(items, t.h)
+	result := make([]FileSize, 0, len(items))
+	for items.Len() > 0 {
+		largest := heap.Remove(&items, 0).(FileSize)
+		result = append(result, largest)
+	}
+	// heap.Remove(0) pops the minimum repeatedly producing ascending order; reverse it.
+	for i, j := 0, len(result)-1; i < j; i, j = i+1, j-1 {
+		result[i], result[j] = result[j], result[i]
+	}
+	return result
+}
@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/kanywst/scallop/internal/docker"
+	"github.com/kanywst/scallop/internal/utils"
+)
+
+// LoadImage analyzes a Docker `save` tarball or an OCI image layout directly,
+// without requiring the caller to pre-unpack it. It reads the layer list via
+// docker.LoadImage, the same entry point layerscan.go and efficiency.go use
+// (so both the legacy `docker save` manifest.json and an OCI Image Layout's
+// index.json/blobs are understood identically), applies whiteout files in
+// layer order to build the merged rootfs view, and populates
+// SizeInfo.LayerSizes with real per-layer sizes and the command from the
+// image history.
+func LoadImage(path string) (*AnalysisResult, error) {
+	tempDir, err := os.MkdirTemp("", "scallop-load-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	imageDir, err := docker.ExtractImage(path, tempDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract image: %v", err)
+	}
+
+	image, err := docker.LoadImage(imageDir)
+	if err != nil || len(image.Layers) == 0 {
+		// No manifest.json/index.json available: fall back to analyzing
+		// whatever was extracted to disk, without layer attribution.
+		result := AnalyzeImage(imageDir, false)
+		result.ImagePath = path
+		return result, nil
+	}
+
+	mergedDir := filepath.Join(tempDir, "merged")
+	if err := os.MkdirAll(mergedDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create merged rootfs directory: %v", err)
+	}
+	for _, layer := range image.Layers {
+		if err := applyLayer(layer.Path, mergedDir); err != nil {
+			return nil, fmt.Errorf("failed to apply layer %s: %v", layer.Path, err)
+		}
+	}
+
+	result := AnalyzeImage(mergedDir, false)
+	result.ImagePath = path
+
+	if result.SizeInfo != nil {
+		layerSizes := make([]LayerSize, 0, len(image.Layers))
+		for _, layer := range image.Layers {
+			info, err := os.Stat(layer.Path)
+			size := int64(0)
+			if err == nil {
+				size = info.Size()
+			}
+			layerSizes = append(layerSizes, LayerSize{
+				ID:      layer.Digest,
+				Size:    size,
+				Command: layer.History,
+			})
+		}
+		result.SizeInfo.LayerSizes = layerSizes
+	}
+
+	return result, nil
+}
+
+// applyLayer extracts a single layer tarball into destDir via
+// utils.ExtractTarWithOptions, the same hardened, auto-decompressing
+// extractor docker.ExtractImage uses: it transparently handles a
+// gzip/zstd/xz/bzip2-compressed layer.tar.gz/.zst (which getLayerSizes in
+// this package already treats as a normal layer format) as well as a plain
+// layer.tar, honors AUFS/OverlayFS-style whiteout markers (".wh.<name>" and
+// ".wh..wh..opq") so the result reflects the merged rootfs rather than a
+// union of every layer, preserves TypeLink hardlinks, and validates every
+// path and symlink/hardlink target against destDir rather than the bare
+// strings.HasPrefix check this function used to do (which a tar entry
+// naming a sibling directory sharing destDir's prefix, e.g. destDir
+// "/tmp/merged" and an entry resolving to "/tmp/merged-evil", would have
+// passed).
+func applyLayer(tarPath string, destDir string) error {
+	return utils.ExtractTarWithOptions(tarPath, destDir, utils.ExtractOptions{})
+}
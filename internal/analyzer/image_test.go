@@ -0,0 +1,324 @@
+package analyzer
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kanywst/scallop/internal/docker"
+)
+
+// writeLayerTar writes a minimal tar file containing the given entries.
+func writeLayerTar(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create layer tarball: %v", err)
+	}
+	defer file.Close()
+
+	w := tar.NewWriter(file)
+	defer w.Close()
+
+	for name, content := range entries {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := w.WriteHeader(hdr); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write tar content: %v", err)
+		}
+	}
+}
+
+// writeLayerTarGzip writes the same tar entries as writeLayerTar, but
+// gzip-compressed, the way a legacy docker save layer.tar.gz is laid out.
+func writeLayerTarGzip(t *testing.T, path string, entries map[string]string) {
+	t.Helper()
+
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create layer tarball: %v", err)
+	}
+	defer file.Close()
+
+	gz := gzip.NewWriter(file)
+	defer gz.Close()
+
+	w := tar.NewWriter(gz)
+	defer w.Close()
+
+	for name, content := range entries {
+		hdr := &tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}
+		if err := w.WriteHeader(hdr); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+		if _, err := w.Write([]byte(content)); err != nil {
+			t.Fatalf("Failed to write tar content: %v", err)
+		}
+	}
+}
+
+func TestLoadImageAppliesWhiteouts(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "load-image-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Layer 1 adds two files.
+	layer1Dir := filepath.Join(tempDir, "layer1")
+	if err := os.MkdirAll(layer1Dir, 0755); err != nil {
+		t.Fatalf("Failed to create layer1 directory: %v", err)
+	}
+	writeLayerTar(t, filepath.Join(layer1Dir, "layer.tar"), map[string]string{
+		"keep.txt":   "kept across layers",
+		"delete.txt": "removed by a later layer",
+	})
+
+	// Layer 2 whites out delete.txt.
+	layer2Dir := filepath.Join(tempDir, "layer2")
+	if err := os.MkdirAll(layer2Dir, 0755); err != nil {
+		t.Fatalf("Failed to create layer2 directory: %v", err)
+	}
+	writeLayerTar(t, filepath.Join(layer2Dir, "layer.tar"), map[string]string{
+		".wh.delete.txt": "",
+	})
+
+	// manifest.json + config describing the two layers, read the same way
+	// LoadImage itself reads them: via docker.LoadImage.
+	type historyEntry struct {
+		CreatedBy  string `json:"created_by"`
+		EmptyLayer bool   `json:"empty_layer"`
+	}
+	config := struct {
+		History []historyEntry `json:"history"`
+	}{
+		History: []historyEntry{
+			{CreatedBy: "COPY keep.txt delete.txt /", EmptyLayer: false},
+			{CreatedBy: "RUN rm delete.txt", EmptyLayer: false},
+		},
+	}
+	configData, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "config.json"), configData, 0644); err != nil {
+		t.Fatalf("Failed to write config.json: %v", err)
+	}
+
+	manifest := []struct {
+		Config string   `json:"Config"`
+		Layers []string `json:"Layers"`
+	}{
+		{
+			Config: "config.json",
+			Layers: []string{"layer1/layer.tar", "layer2/layer.tar"},
+		},
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("Failed to marshal manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "manifest.json"), manifestData, 0644); err != nil {
+		t.Fatalf("Failed to write manifest.json: %v", err)
+	}
+
+	image, err := docker.LoadImage(tempDir)
+	if err != nil {
+		t.Fatalf("docker.LoadImage failed: %v", err)
+	}
+	if len(image.Layers) != 2 {
+		t.Fatalf("len(image.Layers) = %d, expected 2", len(image.Layers))
+	}
+	if image.Layers[0].History != "COPY keep.txt delete.txt /" {
+		t.Errorf("image.Layers[0].History = %q, unexpected", image.Layers[0].History)
+	}
+
+	mergedDir := filepath.Join(tempDir, "merged")
+	if err := os.MkdirAll(mergedDir, 0755); err != nil {
+		t.Fatalf("Failed to create merged directory: %v", err)
+	}
+	for _, layer := range image.Layers {
+		if err := applyLayer(layer.Path, mergedDir); err != nil {
+			t.Fatalf("applyLayer failed: %v", err)
+		}
+	}
+
+	if _, err := os.Stat(filepath.Join(mergedDir, "keep.txt")); err != nil {
+		t.Errorf("keep.txt should exist in the merged rootfs: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(mergedDir, "delete.txt")); !os.IsNotExist(err) {
+		t.Errorf("delete.txt should have been removed by the whiteout in layer2")
+	}
+}
+
+func TestLoadImageHandlesOCILayoutTarball(t *testing.T) {
+	tempDir := t.TempDir()
+
+	layer1 := buildLayerTar(t, map[string]string{"keep.txt": "kept across layers", "delete.txt": "removed by a later layer"})
+	layer2 := buildLayerTar(t, map[string]string{".wh.delete.txt": ""})
+
+	config, err := json.Marshal(map[string]interface{}{
+		"history": []map[string]interface{}{
+			{"created_by": "COPY keep.txt delete.txt /"},
+			{"created_by": "RUN rm delete.txt"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal image config: %v", err)
+	}
+
+	manifest, err := json.Marshal(map[string]interface{}{
+		"config": map[string]string{"digest": "sha256:config"},
+		"layers": []map[string]string{
+			{"digest": "sha256:layer1"},
+			{"digest": "sha256:layer2"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal manifest blob: %v", err)
+	}
+
+	index, err := json.Marshal(map[string]interface{}{
+		"manifests": []map[string]string{{"digest": "sha256:manifest"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal index.json: %v", err)
+	}
+
+	path := filepath.Join(tempDir, "oci-image.tar")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create tarball: %v", err)
+	}
+	w := tar.NewWriter(f)
+	writeTarEntry(t, w, "index.json", index)
+	writeTarEntry(t, w, "blobs/sha256/manifest", manifest)
+	writeTarEntry(t, w, "blobs/sha256/config", config)
+	writeTarEntry(t, w, "blobs/sha256/layer1", layer1)
+	writeTarEntry(t, w, "blobs/sha256/layer2", layer2)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Failed to close tarball: %v", err)
+	}
+
+	result, err := LoadImage(path)
+	if err != nil {
+		t.Fatalf("LoadImage failed: %v", err)
+	}
+
+	if result.SizeInfo == nil {
+		t.Fatal("expected SizeInfo to be populated")
+	}
+	if len(result.SizeInfo.LayerSizes) != 2 {
+		t.Fatalf("len(LayerSizes) = %d, expected 2", len(result.SizeInfo.LayerSizes))
+	}
+	if result.SizeInfo.LayerSizes[0].Command != "COPY keep.txt delete.txt /" {
+		t.Errorf("LayerSizes[0].Command = %q, expected the image history's created_by", result.SizeInfo.LayerSizes[0].Command)
+	}
+
+	// The merged rootfs should reflect the whiteout, and LargestFiles must
+	// come from that merged view - not the raw extraction directory, which
+	// would otherwise also surface index.json, blobs/sha256/*, and the
+	// .extracted per-layer directories as if they were image content.
+	for _, file := range result.SizeInfo.LargestFiles {
+		if file.Path == "index.json" || strings.Contains(file.Path, "blobs/sha256") {
+			t.Errorf("LargestFiles leaked a raw layout file: %q", file.Path)
+		}
+	}
+
+	var sawKeep bool
+	for _, file := range result.SizeInfo.LargestFiles {
+		if file.Path == "keep.txt" {
+			sawKeep = true
+		}
+		if file.Path == "delete.txt" {
+			t.Errorf("delete.txt should have been removed by layer 2's whiteout, found in LargestFiles")
+		}
+	}
+	if !sawKeep {
+		t.Errorf("expected keep.txt in LargestFiles")
+	}
+}
+
+func TestApplyLayerDecompressesGzipLayer(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "apply-layer-gzip-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tarPath := filepath.Join(tempDir, "layer.tar.gz")
+	writeLayerTarGzip(t, tarPath, map[string]string{"keep.txt": "kept across layers"})
+
+	destDir := filepath.Join(tempDir, "merged")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create merged directory: %v", err)
+	}
+	if err := applyLayer(tarPath, destDir); err != nil {
+		t.Fatalf("applyLayer failed on a gzip-compressed layer: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(destDir, "keep.txt"))
+	if err != nil {
+		t.Fatalf("keep.txt should exist in the merged rootfs: %v", err)
+	}
+	if string(content) != "kept across layers" {
+		t.Errorf("keep.txt content = %q, expected %q", content, "kept across layers")
+	}
+}
+
+func TestApplyLayerPreservesHardlinks(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "apply-layer-hardlink-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tarPath := filepath.Join(tempDir, "layer.tar")
+	file, err := os.Create(tarPath)
+	if err != nil {
+		t.Fatalf("Failed to create layer tarball: %v", err)
+	}
+	w := tar.NewWriter(file)
+	content := "original content"
+	if err := w.WriteHeader(&tar.Header{Name: "original.txt", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("Failed to write tar header: %v", err)
+	}
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatalf("Failed to write tar content: %v", err)
+	}
+	if err := w.WriteHeader(&tar.Header{Name: "linked.txt", Typeflag: tar.TypeLink, Linkname: "original.txt"}); err != nil {
+		t.Fatalf("Failed to write hardlink header: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	if err := file.Close(); err != nil {
+		t.Fatalf("Failed to close layer tarball: %v", err)
+	}
+
+	destDir := filepath.Join(tempDir, "merged")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create merged directory: %v", err)
+	}
+	if err := applyLayer(tarPath, destDir); err != nil {
+		t.Fatalf("applyLayer failed: %v", err)
+	}
+
+	linked, err := os.ReadFile(filepath.Join(destDir, "linked.txt"))
+	if err != nil {
+		t.Fatalf("linked.txt should have been created as a hardlink: %v", err)
+	}
+	if string(linked) != content {
+		t.Errorf("linked.txt content = %q, expected %q", linked, content)
+	}
+}
@@ -0,0 +1,114 @@
+package analyzer
+
+import (
+	"archive/tar"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// AnalyzeImageTarball analyzes a "docker save" tarball -- a single outer
+// tar containing each layer's own tarball as a nested entry, alongside
+// manifest.json and an image config -- directly from disk, without
+// extracting anything: each inner layer entry's content is streamed
+// straight into AnalyzeLayerStream. This makes two passes over path, which
+// must therefore be a regular, seekable file (not a pipe): the first reads
+// manifest.json to learn which entries are layer tarballs, the second
+// streams just those entries' content layer-by-layer. A caller reading a
+// "docker save" stream directly off a pipe should write it to a temp file
+// first; AnalyzeLayerStream itself has no such restriction once the layer
+// boundaries are known.
+//
+// The returned SizeInfo is a raw sum across all layers: unlike
+// AnalyzeEfficiency, it does not reconcile a path overwritten or whited out
+// by a later layer, so LargestDirs is left empty rather than reported
+// incorrectly; TotalSize, FileTypeBreakdown, and LargestFiles reflect every
+// layer's own writes, not the merged rootfs the image actually produces.
+func AnalyzeImageTarball(path string) (*SizeInfo, error) {
+	layerNames, err := findManifestLayerNames(path)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image tarball: %v", err)
+	}
+	defer f.Close()
+
+	info := &SizeInfo{FileTypeBreakdown: make(map[string]int64)}
+	top := newTopKFiles(10)
+
+	reader := tar.NewReader(f)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading image tarball: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg || !layerNames[filepath.Clean(header.Name)] {
+			continue
+		}
+
+		layer, err := AnalyzeLayerStream(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to analyze layer %s: %v", header.Name, err)
+		}
+
+		info.TotalSize += layer.TotalSize
+		for ext, size := range layer.FileTypeBreakdown {
+			info.FileTypeBreakdown[ext] += size
+		}
+		for _, file := range layer.LargestFiles {
+			top.Add(file)
+		}
+	}
+
+	info.LargestFiles = top.Sorted()
+	return info, nil
+}
+
+// findManifestLayerNames opens path and reads just far enough to find
+// manifest.json, returning the set of entry names (cleaned, as they appear
+// in the outer tar) it lists as layer tarballs.
+func findManifestLayerNames(path string) (map[string]bool, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open image tarball: %v", err)
+	}
+	defer f.Close()
+
+	reader := tar.NewReader(f)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil, fmt.Errorf("manifest.json not found in %s", path)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading image tarball: %v", err)
+		}
+		if filepath.Clean(header.Name) != "manifest.json" {
+			continue
+		}
+
+		var manifests []struct {
+			Layers []string `json:"Layers"`
+		}
+		if err := json.NewDecoder(reader).Decode(&manifests); err != nil {
+			return nil, fmt.Errorf("failed to parse manifest.json: %v", err)
+		}
+		if len(manifests) == 0 {
+			return nil, fmt.Errorf("manifest.json contains no images")
+		}
+
+		names := make(map[string]bool, len(manifests[0].Layers))
+		for _, l := range manifests[0].Layers {
+			names[filepath.Clean(l)] = true
+		}
+		return names, nil
+	}
+}
@@ -0,0 +1,107 @@
+package analyzer
+
+import (
+	"archive/tar"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeTarEntry(t *testing.T, w *tar.Writer, name string, content []byte) {
+	t.Helper()
+
+	if err := w.WriteHeader(&tar.Header{Name: name, Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("Failed to write tar header for %s: %v", name, err)
+	}
+	if _, err := w.Write(content); err != nil {
+		t.Fatalf("Failed to write tar content for %s: %v", name, err)
+	}
+}
+
+func buildLayerTar(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	for name, content := range files {
+		writeTarEntry(t, w, name, []byte(content))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close layer tar writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestAnalyzeImageTarball(t *testing.T) {
+	layer1 := buildLayerTar(t, map[string]string{"a.txt": "hello"})
+	layer2 := buildLayerTar(t, map[string]string{"b.log": "world!!"})
+
+	manifest, err := json.Marshal([]map[string]interface{}{
+		{"Layers": []string{"layer1/layer.tar", "layer2/layer.tar"}},
+	})
+	if err != nil {
+		t.Fatalf("Failed to marshal manifest.json: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "image.tar")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create image tarball: %v", err)
+	}
+
+	w := tar.NewWriter(f)
+	writeTarEntry(t, w, "layer1/layer.tar", layer1)
+	writeTarEntry(t, w, "manifest.json", manifest)
+	writeTarEntry(t, w, "layer2/layer.tar", layer2)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close image tar writer: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Failed to close image tarball: %v", err)
+	}
+
+	info, err := AnalyzeImageTarball(path)
+	if err != nil {
+		t.Fatalf("AnalyzeImageTarball failed: %v", err)
+	}
+
+	wantTotal := int64(len("hello") + len("world!!"))
+	if info.TotalSize != wantTotal {
+		t.Errorf("TotalSize = %d, expected %d", info.TotalSize, wantTotal)
+	}
+	if size := info.FileTypeBreakdown[".txt"]; size != int64(len("hello")) {
+		t.Errorf("FileTypeBreakdown[.txt] = %d, expected %d", size, len("hello"))
+	}
+	if size := info.FileTypeBreakdown[".log"]; size != int64(len("world!!")) {
+		t.Errorf("FileTypeBreakdown[.log] = %d, expected %d", size, len("world!!"))
+	}
+	if len(info.LargestFiles) != 2 {
+		t.Errorf("len(LargestFiles) = %d, expected 2", len(info.LargestFiles))
+	}
+	if info.LargestDirs != nil {
+		t.Errorf("LargestDirs = %v, expected nil (not reconciled across layers)", info.LargestDirs)
+	}
+}
+
+func TestAnalyzeImageTarballMissingManifest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "image.tar")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create image tarball: %v", err)
+	}
+
+	w := tar.NewWriter(f)
+	writeTarEntry(t, w, "layer1/layer.tar", buildLayerTar(t, map[string]string{"a.txt": "hello"}))
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close image tar writer: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatalf("Failed to close image tarball: %v", err)
+	}
+
+	if _, err := AnalyzeImageTarball(path); err == nil {
+		t.Fatal("expected an error when manifest.json is missing, got nil")
+	}
+}
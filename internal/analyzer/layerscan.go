@@ -0,0 +1,203 @@
+package analyzer
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/kanywst/scallop/internal/docker"
+	"github.com/spf13/afero"
+)
+
+// fileIdentity is the (size, modification time) pair a layer tar header
+// contributes for a given path, used to recognize when a later layer
+// re-adds a file whose content is unchanged, so it isn't scanned twice.
+// Extraction (utils.ExtractTar) does not preserve a tar entry's ModTime, so
+// this identity can only be computed by reading each layer's tarball
+// directly rather than walking its already-extracted directory.
+type fileIdentity struct {
+	size    int64
+	modTime time.Time
+}
+
+// layerFinding records where in result.Issues a SENSITIVE_FILE or
+// HARDCODED_SECRET issue was appended, and which layer introduced it, so a
+// later layer's whiteout can go back and downgrade its severity.
+type layerFinding struct {
+	issueIdx int
+	addedAt  int
+}
+
+// AnalyzeSecurityPerLayer scans a Docker/OCI image one layer at a time, in
+// the order docker.LoadImage reports them, instead of AnalyzeSecurity's
+// single pass over the merged, fully-extracted rootfs. Every SecurityIssue
+// it returns is attributed to the layer that introduced it (LayerIndex,
+// LayerDiffID) and, when the image config's history has an entry for that
+// layer, the Dockerfile instruction that produced it (Command).
+//
+// A sensitive file or hardcoded secret is reported at its usual severity if
+// no later layer's whiteout (.wh.<name> or .wh..wh..opq) removes it, since
+// it is present in the final image; if a later layer does remove it,
+// severity is downgraded to LOW, since the content is gone from the merged
+// filesystem but still recoverable by anyone who can pull the earlier layer
+// blob. A file reappearing in a later layer with the same path, size, and
+// modification time as one already scanned is assumed unchanged and is not
+// scanned again.
+//
+// Vulnerable-package detection is layer-scoped too: each layer's already-
+// extracted directory (see docker.Layer.ExtractedDir) is enumerated and
+// matched against db independently, so a vulnerable package introduced in
+// one layer and never removed is attributed to the layer that added it.
+func AnalyzeSecurityPerLayer(imagePath string, db VulnerabilityDB, opts ...Option) (*SecurityResult, error) {
+	o := newOptions(opts...)
+	if o.vulnDBCacheTTL > 0 {
+		db = newCachingVulnDB(db, o.vulnDBCacheTTL)
+	}
+
+	image, err := docker.LoadImage(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &SecurityResult{}
+	cache := newVerifierCache()
+	scanned := make(map[string]fileIdentity)
+	findings := make(map[string]layerFinding)
+
+	for i, layer := range image.Layers {
+		whiteouts, layerIssues, err := scanLayerTar(o.fs, layer, scanned, cache, o.verifiers)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan layer %d (%s): %v", i, layer.Digest, err)
+		}
+
+		for _, path := range whiteouts {
+			f, ok := findings[path]
+			if !ok || f.addedAt >= i {
+				continue
+			}
+			issue := &result.Issues[f.issueIdx]
+			issue.Severity = "LOW"
+			issue.Description += " (removed by a later layer's whiteout, but still recoverable from this layer's blob)"
+		}
+
+		for _, issue := range layerIssues {
+			issue.LayerIndex = i
+			issue.LayerDiffID = layer.Digest
+			issue.Command = layer.History
+			result.Issues = append(result.Issues, issue)
+
+			if issue.Type == "SENSITIVE_FILE" || issue.Type == "HARDCODED_SECRET" {
+				path := strings.SplitN(issue.Path, ":", 2)[0]
+				findings[path] = layerFinding{issueIdx: len(result.Issues) - 1, addedAt: i}
+			}
+		}
+
+		if layer.ExtractedDir == "" {
+			continue
+		}
+		pkgs, err := EnumeratePackages(layer.ExtractedDir)
+		if err != nil || len(pkgs) == 0 {
+			continue
+		}
+		vulnIssues, err := db.Query(pkgs)
+		if err != nil {
+			continue
+		}
+		for _, issue := range vulnIssues {
+			issue.LayerIndex = i
+			issue.LayerDiffID = layer.Digest
+			issue.Command = layer.History
+			result.Issues = append(result.Issues, issue)
+		}
+	}
+
+	for _, issue := range result.Issues {
+		switch issue.Severity {
+		case "HIGH", "CRITICAL":
+			result.HighSeverity++
+		case "MEDIUM":
+			result.MediumSeverity++
+		case "LOW":
+			result.LowSeverity++
+		}
+	}
+	result.TotalIssues = len(result.Issues)
+
+	return result, nil
+}
+
+// scanLayerTar reads one layer's tarball directly and returns the
+// SENSITIVE_FILE/HARDCODED_SECRET issues found in it, plus the paths this
+// layer's whiteout markers remove. scanned is updated in place so a file
+// already scanned with the same (path, size, modTime) in an earlier layer
+// is skipped here.
+func scanLayerTar(fs afero.Fs, layer docker.Layer, scanned map[string]fileIdentity, cache *verifierCache, verifiers []Verifier) ([]string, []SecurityIssue, error) {
+	r, closeStream, err := openLayerStream(fs, layer.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer closeStream()
+
+	var whiteouts []string
+	var issues []SecurityIssue
+
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("error reading layer tarball: %v", err)
+		}
+
+		name := filepath.Clean(header.Name)
+		dir := filepath.Dir(name)
+		base := filepath.Base(name)
+
+		if base == ".wh..wh..opq" {
+			prefix := dir + string(filepath.Separator)
+			for path := range scanned {
+				if strings.HasPrefix(path, prefix) {
+					whiteouts = append(whiteouts, path)
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(base, ".wh.") {
+			whiteouts = append(whiteouts, filepath.Join(dir, strings.TrimPrefix(base, ".wh.")))
+			continue
+		}
+
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		identity := fileIdentity{size: header.Size, modTime: header.ModTime}
+		if existing, ok := scanned[name]; ok && existing == identity {
+			continue
+		}
+		scanned[name] = identity
+
+		for _, pattern := range sensitiveFilePatterns {
+			if strings.Contains(strings.ToLower(name), pattern.pattern) {
+				issues = append(issues, SecurityIssue{
+					Type:        "SENSITIVE_FILE",
+					Path:        name,
+					Description: pattern.desc,
+					Severity:    pattern.severity,
+				})
+				break
+			}
+		}
+
+		if !isBinaryExt(name) {
+			issues = append(issues, scanLinesForSecrets(io.LimitReader(reader, header.Size), name, cache, verifiers, nil)...)
+		}
+	}
+
+	return whiteouts, issues, nil
+}
@@ -0,0 +1,130 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAnalyzeSecurityPerLayer(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "per-layer-security-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Layer 1 adds a sensitive file (later whited out), a hardcoded secret
+	// that survives to the final image, and a secret-bearing file that
+	// layer 2 re-adds unchanged.
+	layer1Dir := filepath.Join(tempDir, "layer1")
+	if err := os.MkdirAll(layer1Dir, 0755); err != nil {
+		t.Fatalf("Failed to create layer1 directory: %v", err)
+	}
+	writeLayerTar(t, filepath.Join(layer1Dir, "layer.tar"), map[string]string{
+		"config/.env":  "SECRET=shouldnotship",
+		"app.js":       "const password = 'supersecretpassword123';",
+		"unchanged.js": "const token = 'anothersecretvalue456';",
+	})
+
+	// Layer 2 whites out config/.env and re-adds unchanged.js with identical
+	// content (same size, same zero-value tar ModTime as layer 1's entry),
+	// which should be recognized as unchanged and skipped rather than
+	// rescanned.
+	layer2Dir := filepath.Join(tempDir, "layer2")
+	if err := os.MkdirAll(layer2Dir, 0755); err != nil {
+		t.Fatalf("Failed to create layer2 directory: %v", err)
+	}
+	writeLayerTar(t, filepath.Join(layer2Dir, "layer.tar"), map[string]string{
+		"config/.wh..env": "",
+		"unchanged.js":    "const token = 'anothersecretvalue456';",
+	})
+
+	result, err := AnalyzeSecurityPerLayer(tempDir, NewBuiltinVulnerabilityDB())
+	if err != nil {
+		t.Fatalf("AnalyzeSecurityPerLayer failed: %v", err)
+	}
+
+	var envIssue, secretIssue *SecurityIssue
+	unchangedHits := 0
+	for i := range result.Issues {
+		issue := &result.Issues[i]
+		path := strings.SplitN(issue.Path, ":", 2)[0]
+
+		switch {
+		case issue.Type == "SENSITIVE_FILE" && path == "config/.env":
+			envIssue = issue
+		case issue.Type == "HARDCODED_SECRET" && path == "app.js":
+			secretIssue = issue
+		case path == "unchanged.js":
+			unchangedHits++
+		}
+	}
+
+	if envIssue == nil {
+		t.Fatalf("expected a SENSITIVE_FILE issue for config/.env")
+	}
+	if envIssue.Severity != "LOW" {
+		t.Errorf("config/.env severity = %q, expected LOW (whited out by layer 2)", envIssue.Severity)
+	}
+	if envIssue.LayerIndex != 0 {
+		t.Errorf("config/.env LayerIndex = %d, expected 0", envIssue.LayerIndex)
+	}
+	if envIssue.LayerDiffID != "layer1" {
+		t.Errorf("config/.env LayerDiffID = %q, expected %q", envIssue.LayerDiffID, "layer1")
+	}
+
+	if secretIssue == nil {
+		t.Fatalf("expected a HARDCODED_SECRET issue for app.js")
+	}
+	if secretIssue.Severity != "HIGH" {
+		t.Errorf("app.js severity = %q, expected HIGH (never whited out)", secretIssue.Severity)
+	}
+
+	if unchangedHits != 1 {
+		t.Errorf("unchanged.js raised %d issues, expected exactly 1 (layer 2's identical re-add should be skipped, not rescanned)", unchangedHits)
+	}
+}
+
+func TestAnalyzeSecurityPerLayerCachesVulnDBQueries(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "per-layer-vulndb-cache-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	// Two layers, each already extracted (ExtractedDir, per extractedDirFor's
+	// legacy-layout convention, is the "extracted" directory alongside
+	// layer.tar) with the same package.json, so EnumeratePackages reports the
+	// same package/version in both layers.
+	packageJSON := `{"dependencies": {"vulnerable-pkg": "1.0.0"}}`
+	for _, name := range []string{"layer1", "layer2"} {
+		layerDir := filepath.Join(tempDir, name)
+		if err := os.MkdirAll(layerDir, 0755); err != nil {
+			t.Fatalf("Failed to create %s directory: %v", name, err)
+		}
+		writeLayerTar(t, filepath.Join(layerDir, "layer.tar"), map[string]string{"keep.txt": "kept"})
+
+		extractedDir := filepath.Join(layerDir, "extracted")
+		if err := os.MkdirAll(extractedDir, 0755); err != nil {
+			t.Fatalf("Failed to create %s/extracted directory: %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(extractedDir, "package.json"), []byte(packageJSON), 0644); err != nil {
+			t.Fatalf("Failed to write package.json in %s: %v", name, err)
+		}
+	}
+
+	stub := &countingVulnDB{}
+	result, err := AnalyzeSecurityPerLayer(tempDir, stub, WithVulnDBCacheTTL(time.Minute))
+	if err != nil {
+		t.Fatalf("AnalyzeSecurityPerLayer failed: %v", err)
+	}
+	if result == nil {
+		t.Fatal("AnalyzeSecurityPerLayer returned a nil result")
+	}
+
+	if stub.queried != 1 {
+		t.Errorf("stub.queried = %d, expected 1 (layer 2's identical package should be served from cache)", stub.queried)
+	}
+}
@@ -0,0 +1,100 @@
+package analyzer
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/kanywst/scallop/internal/utils"
+)
+
+// LayerAnalysis is the size/type breakdown of a single layer tarball,
+// computed by AnalyzeLayerStream directly from its tar stream without
+// extracting anything to disk.
+type LayerAnalysis struct {
+	TotalSize         int64            `json:"totalSize"`
+	LargestFiles      []FileSize       `json:"largestFiles,omitempty"`
+	FileTypeBreakdown map[string]int64 `json:"fileTypeBreakdown,omitempty"`
+	// Whiteouts lists the paths this layer removes via a ".wh.<name>"
+	// marker, or makes opaque via ".wh..wh..opq", in the order encountered.
+	Whiteouts []string `json:"whiteouts,omitempty"`
+}
+
+// AnalyzeLayerStream consumes a single (possibly compressed) layer tar
+// stream r directly via archive/tar, mirroring the approach
+// AnalyzeSizeStreaming already takes for a whole image's layers, but
+// against an arbitrary io.Reader rather than a path on an afero.Fs -- so a
+// caller can feed it a layer read straight off a pipe, with no filesystem
+// involved at all. It accumulates total size, file-type breakdown, the
+// top-10 largest files, and the list of whiteout/opaque markers the layer
+// contains.
+func AnalyzeLayerStream(r io.Reader) (*LayerAnalysis, error) {
+	stream, closeStream, err := decompressStream(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeStream()
+
+	result := &LayerAnalysis{FileTypeBreakdown: make(map[string]int64)}
+	top := newTopKFiles(10)
+	// knownFileSizes resolves a hardlink's Linkname to the size already
+	// recorded for it, the same way streamLayerSizes does.
+	knownFileSizes := make(map[string]int64)
+
+	reader := tar.NewReader(stream)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading layer tarball: %v", err)
+		}
+
+		name := filepath.Clean(header.Name)
+		base := filepath.Base(name)
+
+		if base == ".wh..wh..opq" {
+			result.Whiteouts = append(result.Whiteouts, filepath.Dir(name))
+			continue
+		}
+		if strings.HasPrefix(base, ".wh.") {
+			result.Whiteouts = append(result.Whiteouts, filepath.Join(filepath.Dir(name), strings.TrimPrefix(base, ".wh.")))
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg:
+			knownFileSizes[name] = header.Size
+			result.TotalSize += header.Size
+			top.Add(FileSize{Path: name, Size: header.Size})
+
+			ext := filepath.Ext(name)
+			if ext == "" {
+				ext = "[no extension]"
+			}
+			result.FileTypeBreakdown[ext] += header.Size
+		case tar.TypeLink:
+			top.Add(FileSize{Path: name, Size: knownFileSizes[filepath.Clean(header.Linkname)]})
+		}
+	}
+
+	result.LargestFiles = top.Sorted()
+	return result, nil
+}
+
+// decompressStream wraps r in the decompressor matching its leading magic
+// bytes (gzip, zstd, xz, bzip2), or returns it unchanged if none match. It
+// delegates to utils.DecompressStream, the same detection ExtractTar now
+// uses, since a caller of AnalyzeLayerStream may have nothing addressable
+// as a file -- e.g. a single layer entry read out of a "docker save"
+// tarball -- the same situation utils.DecompressStream was built for.
+func decompressStream(r io.Reader) (io.Reader, func() error, error) {
+	rc, err := utils.DecompressStream(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	return rc, rc.Close, nil
+}
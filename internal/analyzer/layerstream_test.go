@@ -0,0 +1,94 @@
+package analyzer
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"testing"
+)
+
+func writeTestLayerTar(t *testing.T, w *tar.Writer) {
+	t.Helper()
+
+	content := "original file content"
+	if err := w.WriteHeader(&tar.Header{Name: "dir/original.txt", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("Failed to write tar header: %v", err)
+	}
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatalf("Failed to write tar content: %v", err)
+	}
+
+	if err := w.WriteHeader(&tar.Header{Name: ".wh.ghost.txt", Mode: 0644, Size: 0, Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("Failed to write whiteout header: %v", err)
+	}
+
+	if err := w.WriteHeader(&tar.Header{Name: "opaque/.wh..wh..opq", Mode: 0644, Size: 0, Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("Failed to write opaque marker header: %v", err)
+	}
+}
+
+func TestAnalyzeLayerStream(t *testing.T) {
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	writeTestLayerTar(t, w)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+
+	result, err := AnalyzeLayerStream(&buf)
+	if err != nil {
+		t.Fatalf("AnalyzeLayerStream failed: %v", err)
+	}
+
+	wantTotal := int64(len("original file content"))
+	if result.TotalSize != wantTotal {
+		t.Errorf("TotalSize = %d, expected %d", result.TotalSize, wantTotal)
+	}
+	if size := result.FileTypeBreakdown[".txt"]; size != wantTotal {
+		t.Errorf("FileTypeBreakdown[.txt] = %d, expected %d", size, wantTotal)
+	}
+
+	if len(result.Whiteouts) != 2 {
+		t.Fatalf("len(Whiteouts) = %d, expected 2", len(result.Whiteouts))
+	}
+	wantWhiteouts := map[string]bool{"ghost.txt": true, "opaque": true}
+	for _, wh := range result.Whiteouts {
+		if !wantWhiteouts[wh] {
+			t.Errorf("unexpected whiteout %q", wh)
+		}
+	}
+
+	for _, f := range result.LargestFiles {
+		if f.Path == "ghost.txt" || f.Path == ".wh.ghost.txt" {
+			t.Errorf("LargestFiles should not include the whiteout entry, got %q", f.Path)
+		}
+	}
+}
+
+func TestAnalyzeLayerStreamGzipCompressed(t *testing.T) {
+	var inner bytes.Buffer
+	w := tar.NewWriter(&inner)
+	writeTestLayerTar(t, w)
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+
+	var gzBuf bytes.Buffer
+	gz := gzip.NewWriter(&gzBuf)
+	if _, err := gz.Write(inner.Bytes()); err != nil {
+		t.Fatalf("Failed to gzip tar content: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+
+	result, err := AnalyzeLayerStream(&gzBuf)
+	if err != nil {
+		t.Fatalf("AnalyzeLayerStream failed: %v", err)
+	}
+
+	wantTotal := int64(len("original file content"))
+	if result.TotalSize != wantTotal {
+		t.Errorf("TotalSize = %d, expected %d", result.TotalSize, wantTotal)
+	}
+}
@@ -0,0 +1,91 @@
+package analyzer
+
+import (
+	"time"
+
+	"github.com/spf13/afero"
+)
+
+// options holds the configurable dependencies for the analyzer functions.
+type options struct {
+	fs             afero.Fs
+	verifiers      []Verifier
+	verifyDigests  bool
+	vulnDB         VulnerabilityDB
+	vulnDBCacheTTL time.Duration
+}
+
+// Option configures the behavior of the analyzer functions.
+type Option func(*options)
+
+// WithFS sets the filesystem used to perform the analysis. This allows
+// callers to point scallop at an in-memory filesystem for tests, a
+// chrooted/base-path filesystem, or any other afero.Fs implementation,
+// instead of always touching the real OS filesystem.
+func WithFS(fs afero.Fs) Option {
+	return func(o *options) {
+		o.fs = fs
+	}
+}
+
+// WithSecretVerifiers enables live verification of candidate secrets found
+// by findHardcodedSecrets: a matching Verifier's RuleID is called to
+// confirm a candidate is still an active credential, upgrading its severity
+// to CRITICAL. Verification is off by default (an empty options.verifiers)
+// specifically because it makes outbound network calls using the
+// credential's own value; callers must opt in by passing verifiers here.
+func WithSecretVerifiers(verifiers ...Verifier) Option {
+	return func(o *options) {
+		o.verifiers = append(o.verifiers, verifiers...)
+	}
+}
+
+// WithVerifyDigests makes AnalyzeSize run VerifyLayers and fail with an
+// error listing every mismatch, instead of only recording a best-effort
+// IntegrityError string. Off by default since hashing every layer twice
+// (compressed blob and decompressed stream) adds real cost on a large
+// image; a caller surfaces pkg/config.SecurityConfig.VerifyDigests through
+// this option to let CI fail a build whose layers don't match its manifest.
+func WithVerifyDigests(verify bool) Option {
+	return func(o *options) {
+		o.verifyDigests = verify
+	}
+}
+
+// WithVulnDB selects the VulnerabilityDB AnalyzeSecurity and
+// AnalyzeSecurityWithPolicy match enumerated packages against, the same way
+// AnalyzeSecurityWithDB's explicit db parameter does, but through the
+// shared Option mechanism so a caller that only has an Option list (rather
+// than a function that takes db directly) can still choose OSV.dev, an
+// offline bundle, or a stub in tests. Falls back to defaultVulnerabilityDB
+// when not given. AnalyzeSecurityPerLayer already takes db as an explicit
+// parameter, so this option has no effect on it.
+func WithVulnDB(db VulnerabilityDB) Option {
+	return func(o *options) {
+		o.vulnDB = db
+	}
+}
+
+// WithVulnDBCacheTTL wraps whichever VulnerabilityDB AnalyzeSecurity,
+// AnalyzeSecurityWithPolicy, and AnalyzeSecurityPerLayer use (WithVulnDB's,
+// defaultVulnerabilityDB, or AnalyzeSecurityPerLayer's explicit db
+// parameter) in a per-process cache (see cachingVulnDB) that reuses a
+// package's previous Query result for ttl instead of re-querying db for it.
+// Zero (the default) disables caching. Most valuable for
+// AnalyzeSecurityPerLayer, which queries once per layer and often sees the
+// same package version recur across several of them.
+func WithVulnDBCacheTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.vulnDBCacheTTL = ttl
+	}
+}
+
+// newOptions builds an options value from the provided Option list, defaulting
+// to the real OS filesystem when no WithFS option is given.
+func newOptions(opts ...Option) *options {
+	o := &options{fs: afero.NewOsFs()}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestAnalyzeDirectoryWithMemMapFs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	// Build a small directory structure in memory, no temp dir needed
+	if err := fs.MkdirAll("/image/dir1", 0755); err != nil {
+		t.Fatalf("Failed to create directory: %v", err)
+	}
+	if err := afero.WriteFile(fs, "/image/file1.txt", []byte("hello"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := afero.WriteFile(fs, "/image/dir1/file2.txt", []byte("world"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	info, err := AnalyzeDirectory("/image", false, WithFS(fs))
+	if err != nil {
+		t.Fatalf("AnalyzeDirectory failed: %v", err)
+	}
+
+	if info.FileCount != 2 {
+		t.Errorf("FileCount = %d, expected 2", info.FileCount)
+	}
+	if info.DirCount != 1 {
+		t.Errorf("DirCount = %d, expected 1", info.DirCount)
+	}
+}
+
+func TestAnalyzeSizeWithMemMapFs(t *testing.T) {
+	fs := afero.NewMemMapFs()
+
+	if err := afero.WriteFile(fs, "/image/big.txt", []byte("0123456789"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	info, err := AnalyzeSize("/image", WithFS(fs))
+	if err != nil {
+		t.Fatalf("AnalyzeSize failed: %v", err)
+	}
+
+	if info.TotalSize != 10 {
+		t.Errorf("TotalSize = %d, expected 10", info.TotalSize)
+	}
+}
@@ -0,0 +1,302 @@
+package analyzer
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// VulnerabilityDB is the interface AnalyzeSecurityWithDB matches packages
+// against. It is the same shape as OSVClient: most implementations (the
+// public API, an offline dump) genuinely speak OSV, but defaultVulnerabilityDB
+// can also fall back to a small built-in advisory list that doesn't, so the
+// two names exist for callers who care about the distinction.
+type VulnerabilityDB = OSVClient
+
+// defaultVulnerabilityDB returns the VulnerabilityDB AnalyzeSecurity uses
+// when the caller doesn't supply one: a previously cached OSV bundle (see
+// FetchOSVBundle) under the user's cache directory if one exists, or a small
+// built-in advisory list otherwise. It deliberately never reaches out to the
+// network itself, so that AnalyzeSecurity stays usable offline by default.
+func defaultVulnerabilityDB() VulnerabilityDB {
+	if bundlePath, err := DefaultOSVBundlePath(); err == nil {
+		if client, err := NewOfflineOSVClient(bundlePath); err == nil {
+			return client
+		}
+	}
+	return NewBuiltinVulnerabilityDB()
+}
+
+// DefaultOSVBundlePath returns the path FetchOSVBundle writes to when given
+// no explicit cache directory: "<user cache dir>/scallop/osv/bundle.json".
+func DefaultOSVBundlePath() (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(cacheDir, "scallop", "osv", "bundle.json"), nil
+}
+
+// osvEcosystemFeeds maps the Package.Ecosystem values scallop produces to
+// the per-ecosystem zip export names osv.dev publishes at
+// https://osv-vulnerabilities.storage.googleapis.com/<name>/all.zip.
+var osvEcosystemFeeds = map[string]string{
+	"npm":       "npm",
+	"PyPI":      "PyPI",
+	"RubyGems":  "RubyGems",
+	"Go":        "Go",
+	"Debian":    "Debian",
+	"Alpine":    "Alpine",
+	"crates.io": "crates.io",
+}
+
+// FetchOSVBundle downloads the OSV.dev zip export for each of the given
+// ecosystems (see osvEcosystemFeeds for the supported names; a zero-value
+// slice fetches all of them), merges the individual per-vulnerability JSON
+// records each zip contains into a single JSON array, and writes it to
+// "<cacheDir>/bundle.json" (cacheDir defaults to the directory
+// DefaultOSVBundlePath reports). It returns the written bundle's path, which
+// NewOfflineOSVClient can load directly; defaultVulnerabilityDB also reads
+// from the default path automatically once a bundle has been fetched here.
+func FetchOSVBundle(ecosystems []string, cacheDir string) (string, error) {
+	if cacheDir == "" {
+		defaultPath, err := DefaultOSVBundlePath()
+		if err != nil {
+			return "", err
+		}
+		cacheDir = filepath.Dir(defaultPath)
+	}
+	if len(ecosystems) == 0 {
+		for name := range osvEcosystemFeeds {
+			ecosystems = append(ecosystems, name)
+		}
+	}
+
+	if err := os.MkdirAll(cacheDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create OSV cache directory: %v", err)
+	}
+
+	client := &http.Client{Timeout: 2 * time.Minute}
+	var records []json.RawMessage
+	for _, ecosystem := range ecosystems {
+		feed, ok := osvEcosystemFeeds[ecosystem]
+		if !ok {
+			return "", fmt.Errorf("unknown OSV ecosystem %q", ecosystem)
+		}
+
+		found, err := fetchOSVEcosystemZip(client, feed)
+		if err != nil {
+			return "", fmt.Errorf("failed to fetch OSV export for %s: %v", ecosystem, err)
+		}
+		records = append(records, found...)
+	}
+
+	bundle, err := json.Marshal(records)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal OSV bundle: %v", err)
+	}
+
+	bundlePath := filepath.Join(cacheDir, "bundle.json")
+	if err := os.WriteFile(bundlePath, bundle, 0644); err != nil {
+		return "", fmt.Errorf("failed to write OSV bundle: %v", err)
+	}
+	return bundlePath, nil
+}
+
+// fetchOSVEcosystemZip downloads and unpacks a single ecosystem's all.zip
+// export, returning each contained vulnerability record verbatim.
+func fetchOSVEcosystemZip(client *http.Client, feed string) ([]json.RawMessage, error) {
+	url := fmt.Sprintf("https://osv-vulnerabilities.storage.googleapis.com/%s/all.zip", feed)
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(body), int64(len(body)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to open OSV export as zip: %v", err)
+	}
+
+	var records []json.RawMessage
+	for _, f := range zr.File {
+		if !strings.HasSuffix(f.Name, ".json") {
+			continue
+		}
+		rc, err := f.Open()
+		if err != nil {
+			return nil, err
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, json.RawMessage(data))
+	}
+	return records, nil
+}
+
+// versionInRanges reports whether version falls inside any of ranges: at or
+// after the most recent "introduced" event (or the start of time if there is
+// none) and strictly before the next "fixed" event, if any. Events are
+// assumed to already be in the order osv.dev publishes them (ascending).
+func versionInRanges(version string, ranges []osvRange) bool {
+	for _, r := range ranges {
+		affected := false
+		for _, event := range r.Events {
+			if event.Introduced != "" && compareVersions(version, event.Introduced) >= 0 {
+				affected = true
+			}
+			if event.Fixed != "" && compareVersions(version, event.Fixed) >= 0 {
+				affected = false
+			}
+		}
+		if affected {
+			return true
+		}
+	}
+	return false
+}
+
+// rangesFixedVersion returns the first fixed version any range in ranges
+// names, or "" if every affected range is still open.
+func rangesFixedVersion(ranges []osvRange) string {
+	for _, r := range ranges {
+		for _, event := range r.Events {
+			if event.Fixed != "" {
+				return event.Fixed
+			}
+		}
+	}
+	return ""
+}
+
+// compareVersions compares two version strings and returns -1, 0, or 1,
+// the way strings.Compare does. It is a pragmatic approximation of semver
+// and PEP 440 ordering rather than a full implementation of either spec
+// (much like compression.go's magic-byte sniffing stands in for parsing
+// each compression format's container in full): it strips a leading "v" or
+// range prefix, splits on runs of non-alphanumeric characters, and compares
+// corresponding segments numerically when both are numeric, falling back to
+// a lexicographic comparison otherwise. This covers the dotted
+// major.minor.patch versions used by npm, PyPI, RubyGems, Go modules, and
+// Debian/Alpine package versions well enough to place a version before or
+// after a fixed-version boundary.
+func compareVersions(a, b string) int {
+	as := splitVersionSegments(a)
+	bs := splitVersionSegments(b)
+
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var as1, bs1 string
+		if i < len(as) {
+			as1 = as[i]
+		}
+		if i < len(bs) {
+			bs1 = bs[i]
+		}
+
+		an, aIsNum := toInt(as1)
+		bn, bIsNum := toInt(bs1)
+		switch {
+		case aIsNum && bIsNum:
+			if an != bn {
+				if an < bn {
+					return -1
+				}
+				return 1
+			}
+		case as1 != bs1:
+			return strings.Compare(as1, bs1)
+		}
+	}
+	return 0
+}
+
+func splitVersionSegments(v string) []string {
+	v = strings.TrimPrefix(v, "v")
+	v = strings.TrimLeft(v, "^~=<>! ")
+	return strings.FieldsFunc(v, func(r rune) bool {
+		return !(r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+	})
+}
+
+func toInt(s string) (int, bool) {
+	n, err := strconv.Atoi(s)
+	return n, err == nil
+}
+
+// builtinAdvisory is one entry of builtinAdvisories, the small fixed list of
+// known-vulnerable package versions defaultVulnerabilityDB falls back to
+// when no OSV bundle has been cached yet, so scanning without ever calling
+// FetchOSVBundle still catches a handful of well-known issues.
+type builtinAdvisory struct {
+	ecosystem    string
+	name         string
+	fixedBelow   string // versions strictly below this are considered vulnerable
+	fixedVersion string
+	severity     string
+	desc         string
+}
+
+var builtinAdvisories = []builtinAdvisory{
+	{"npm", "lodash", "4.17.21", ">=4.17.21", "HIGH", "Prototype Pollution in lodash"},
+	{"npm", "minimist", "1.2.6", ">=1.2.6", "HIGH", "Prototype Pollution in minimist"},
+	{"npm", "node-fetch", "2.6.7", ">=2.6.7", "HIGH", "Exposure of Sensitive Information in node-fetch"},
+	{"npm", "axios", "0.21.1", ">=0.21.1", "HIGH", "Server-Side Request Forgery in axios"},
+	{"PyPI", "django", "3.2.14", ">=3.2.14", "HIGH", "SQL Injection in Django"},
+	{"PyPI", "flask", "2.0.1", ">=2.0.1", "MEDIUM", "Open Redirect in Flask"},
+	{"PyPI", "requests", "2.26.0", ">=2.26.0", "MEDIUM", "CRLF Injection in Requests"},
+	{"PyPI", "pillow", "9.0.0", ">=9.0.0", "HIGH", "Buffer Overflow in Pillow"},
+}
+
+// builtinOSVClient matches packages against builtinAdvisories. It exists so
+// AnalyzeSecurity has a dependency-free, zero-configuration default database
+// instead of failing outright until FetchOSVBundle has been run once.
+type builtinOSVClient struct{}
+
+// NewBuiltinVulnerabilityDB returns a VulnerabilityDB backed by a small,
+// fixed list of well-known vulnerable package versions, requiring neither
+// network access nor a pre-fetched OSV bundle.
+func NewBuiltinVulnerabilityDB() VulnerabilityDB {
+	return builtinOSVClient{}
+}
+
+func (builtinOSVClient) Query(pkgs []Package) ([]SecurityIssue, error) {
+	var issues []SecurityIssue
+	for _, pkg := range pkgs {
+		for _, adv := range builtinAdvisories {
+			if adv.ecosystem != pkg.Ecosystem || adv.name != pkg.Name {
+				continue
+			}
+			if compareVersions(pkg.Version, adv.fixedBelow) >= 0 {
+				continue
+			}
+			issues = append(issues, SecurityIssue{
+				Type:         "VULNERABLE_PACKAGE",
+				Path:         pkg.Source,
+				Description:  fmt.Sprintf("%s@%s: %s. Update to %s", pkg.Name, pkg.Version, adv.desc, adv.fixedVersion),
+				Severity:     adv.severity,
+				FixedVersion: adv.fixedVersion,
+			})
+		}
+	}
+	return issues, nil
+}
@@ -0,0 +1,64 @@
+package analyzer
+
+import "testing"
+
+func TestCompareVersions(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"4.17.20", "4.17.21", -1},
+		{"4.17.21", "4.17.21", 0},
+		{"4.17.22", "4.17.21", 1},
+		{"v1.2.3", "1.2.3", 0},
+		{"2.6.0", "2.6.7", -1},
+		{"1.10.0", "1.9.0", 1},
+	}
+	for _, c := range cases {
+		if got := compareVersions(c.a, c.b); got != c.want {
+			t.Errorf("compareVersions(%q, %q) = %d, expected %d", c.a, c.b, got, c.want)
+		}
+	}
+}
+
+func TestVersionInRanges(t *testing.T) {
+	ranges := []osvRange{{
+		Type: "SEMVER",
+		Events: []struct {
+			Introduced string `json:"introduced"`
+			Fixed      string `json:"fixed"`
+		}{
+			{Introduced: "0"},
+			{Fixed: "4.17.21"},
+		},
+	}}
+
+	if !versionInRanges("4.17.20", ranges) {
+		t.Errorf("expected 4.17.20 to be affected")
+	}
+	if versionInRanges("4.17.21", ranges) {
+		t.Errorf("expected 4.17.21 (the fixed version) to not be affected")
+	}
+	if rangesFixedVersion(ranges) != "4.17.21" {
+		t.Errorf("rangesFixedVersion = %q, expected 4.17.21", rangesFixedVersion(ranges))
+	}
+}
+
+func TestBuiltinVulnerabilityDB(t *testing.T) {
+	db := NewBuiltinVulnerabilityDB()
+
+	issues, err := db.Query([]Package{
+		{Name: "lodash", Version: "4.17.20", Ecosystem: "npm", Source: "package.json"},
+		{Name: "lodash", Version: "4.17.21", Ecosystem: "npm", Source: "package.json"},
+	})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(issues) != 1 {
+		t.Fatalf("len(issues) = %d, expected 1 (only the vulnerable version should match)", len(issues))
+	}
+	if issues[0].FixedVersion != ">=4.17.21" {
+		t.Errorf("FixedVersion = %q, expected >=4.17.21", issues[0].FixedVersion)
+	}
+}
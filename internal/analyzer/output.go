@@ -0,0 +1,177 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+)
+
+// sarifLevel maps a SecurityIssue severity to a SARIF result level.
+func sarifLevel(severity string) string {
+	switch severity {
+	case "HIGH":
+		return "error"
+	case "MEDIUM":
+		return "warning"
+	default:
+		return "note"
+	}
+}
+
+// sarifLog, sarifRun, sarifTool, sarifDriver, sarifRule, sarifResult, and
+// sarifLocation are a minimal subset of the SARIF 2.1.0 object model needed
+// to report SecurityIssues.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID string `json:"id"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation sarifPhysicalLocation `json:"physicalLocation"`
+}
+
+type sarifPhysicalLocation struct {
+	ArtifactLocation sarifArtifactLocation `json:"artifactLocation"`
+}
+
+type sarifArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+// OutputSARIF writes the security findings in result as a SARIF 2.1.0 log,
+// for ingestion by GitHub code scanning and similar tools.
+func OutputSARIF(result *AnalysisResult, writer io.Writer) error {
+	log := sarifLog{
+		Schema:  "https://raw.githubusercontent.com/oasis-tcs/sarif-spec/master/Schemata/sarif-schema-2.1.0.json",
+		Version: "2.1.0",
+		Runs: []sarifRun{
+			{
+				Tool: sarifTool{
+					Driver: sarifDriver{
+						Name:           "scallop",
+						InformationURI: "https://github.com/kanywst/scallop",
+					},
+				},
+			},
+		},
+	}
+
+	if result.SecurityInfo != nil {
+		seenRules := make(map[string]bool)
+		for _, issue := range result.SecurityInfo.Issues {
+			if !seenRules[issue.Type] {
+				log.Runs[0].Tool.Driver.Rules = append(log.Runs[0].Tool.Driver.Rules, sarifRule{ID: issue.Type})
+				seenRules[issue.Type] = true
+			}
+
+			log.Runs[0].Results = append(log.Runs[0].Results, sarifResult{
+				RuleID:  issue.Type,
+				Level:   sarifLevel(issue.Severity),
+				Message: sarifMessage{Text: issue.Description},
+				Locations: []sarifLocation{
+					{PhysicalLocation: sarifPhysicalLocation{ArtifactLocation: sarifArtifactLocation{URI: issue.Path}}},
+				},
+			})
+		}
+	}
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(log)
+}
+
+// junitTestSuite, junitTestCase, and junitFailure are a minimal subset of
+// the JUnit XML schema understood by CI systems such as Jenkins and GitLab.
+type junitTestSuite struct {
+	XMLName   xml.Name        `xml:"testsuite"`
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestCase struct {
+	Name     string         `xml:"name,attr"`
+	Failures []junitFailure `xml:"failure"`
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",chardata"`
+}
+
+// junitCategories maps the issue types scallop produces to a human-readable
+// JUnit test case name, so each checked category is reported even when it
+// found nothing.
+var junitCategories = []struct {
+	issueType string
+	name      string
+}{
+	{"SENSITIVE_FILE", "sensitive_files"},
+	{"HARDCODED_SECRET", "hardcoded_secrets"},
+	{"VULNERABLE_PACKAGE", "vulnerable_packages"},
+}
+
+// OutputJUnit writes the security findings in result as a JUnit XML report,
+// with one <testcase> per checked category and a <failure> per issue found.
+func OutputJUnit(result *AnalysisResult, writer io.Writer) error {
+	suite := junitTestSuite{Name: "scallop"}
+
+	byType := make(map[string][]SecurityIssue)
+	if result.SecurityInfo != nil {
+		for _, issue := range result.SecurityInfo.Issues {
+			byType[issue.Type] = append(byType[issue.Type], issue)
+		}
+	}
+
+	for _, category := range junitCategories {
+		testCase := junitTestCase{Name: category.name}
+		for _, issue := range byType[category.issueType] {
+			testCase.Failures = append(testCase.Failures, junitFailure{
+				Message: issue.Description,
+				Text:    issue.Path,
+			})
+		}
+		suite.Tests++
+		suite.Failures += len(testCase.Failures)
+		suite.TestCases = append(suite.TestCases, testCase)
+	}
+
+	if _, err := io.WriteString(writer, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(writer)
+	encoder.Indent("", "  ")
+	return encoder.Encode(suite)
+}
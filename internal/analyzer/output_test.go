@@ -0,0 +1,80 @@
+package analyzer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func sampleResultWithIssues() *AnalysisResult {
+	return &AnalysisResult{
+		ImagePath: "test-image",
+		SecurityInfo: &SecurityResult{
+			Issues: []SecurityIssue{
+				{Type: "SENSITIVE_FILE", Path: ".env", Description: "Environment file may contain sensitive information", Severity: "HIGH"},
+				{Type: "HARDCODED_SECRET", Path: "app.py:10", Description: "Hardcoded password", Severity: "HIGH"},
+			},
+			TotalIssues:  2,
+			HighSeverity: 2,
+		},
+	}
+}
+
+func TestOutputSARIF(t *testing.T) {
+	result := sampleResultWithIssues()
+
+	var buf bytes.Buffer
+	if err := OutputSARIF(result, &buf); err != nil {
+		t.Fatalf("OutputSARIF failed: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `"version": "2.1.0"`) {
+		t.Errorf("OutputSARIF did not include the SARIF version")
+	}
+	if !strings.Contains(out, `"ruleId": "SENSITIVE_FILE"`) {
+		t.Errorf("OutputSARIF did not include the SENSITIVE_FILE rule")
+	}
+	if !strings.Contains(out, `"level": "error"`) {
+		t.Errorf("OutputSARIF did not map HIGH severity to the error level")
+	}
+}
+
+func TestOutputJUnit(t *testing.T) {
+	result := sampleResultWithIssues()
+
+	var buf bytes.Buffer
+	if err := OutputJUnit(result, &buf); err != nil {
+		t.Fatalf("OutputJUnit failed: %v", err)
+	}
+
+	out := buf.String()
+	if !strings.Contains(out, `<testsuite name="scallop"`) {
+		t.Errorf("OutputJUnit did not include the testsuite element")
+	}
+	if !strings.Contains(out, `name="sensitive_files"`) {
+		t.Errorf("OutputJUnit did not include the sensitive_files testcase")
+	}
+	if !strings.Contains(out, `<failure message="Hardcoded password"`) {
+		t.Errorf("OutputJUnit did not include the hardcoded secret failure")
+	}
+}
+
+func TestOutputDispatch(t *testing.T) {
+	result := sampleResultWithIssues()
+
+	tests := []string{"text", "json", "sarif", "junit", ""}
+	for _, format := range tests {
+		var buf bytes.Buffer
+		if err := Output(format, result, &buf); err != nil {
+			t.Errorf("Output(%q) failed: %v", format, err)
+		}
+		if buf.Len() == 0 {
+			t.Errorf("Output(%q) produced empty output", format)
+		}
+	}
+
+	if err := Output("unknown", result, &bytes.Buffer{}); err == nil {
+		t.Errorf("Output(\"unknown\") should fail")
+	}
+}
@@ -0,0 +1,123 @@
+package analyzer
+
+import (
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/spf13/afero"
+)
+
+// AnalyzeDirectoryParallel analyzes the directory structure of imagePath the
+// same way AnalyzeDirectory does, but fans subdirectory traversal out across
+// a bounded worker pool instead of walking the tree on a single goroutine.
+// concurrency caps the number of goroutines used; a value <= 0 defaults to
+// runtime.NumCPU(). Files/Dirs slices are sorted before being returned, so
+// the result is deterministic regardless of the order workers finish in.
+func AnalyzeDirectoryParallel(imagePath string, verbose bool, concurrency int, opts ...Option) (*DirectoryInfo, error) {
+	if concurrency <= 0 {
+		concurrency = runtime.NumCPU()
+	}
+
+	o := newOptions(opts...)
+
+	rootEntries, err := afero.ReadDir(o.fs, imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &DirectoryInfo{
+		Path:      imagePath,
+		FileTypes: make(map[string]int),
+	}
+
+	var (
+		mu  sync.Mutex
+		wg  sync.WaitGroup
+		sem = make(chan struct{}, concurrency)
+
+		firstErr error
+	)
+
+	recordFile := func(relPath string, size int64) {
+		info.FileCount++
+		info.Size += size
+		ext := strings.ToLower(filepath.Ext(relPath))
+		if ext == "" {
+			ext = "[no extension]"
+		}
+		info.FileTypes[ext]++
+		if verbose {
+			info.Files = append(info.Files, relPath)
+		}
+	}
+
+	recordDir := func(relPath string) {
+		info.DirCount++
+		if verbose {
+			info.Dirs = append(info.Dirs, relPath)
+		}
+	}
+
+	for _, entry := range rootEntries {
+		entry := entry
+		childPath := filepath.Join(imagePath, entry.Name())
+
+		if !entry.IsDir() {
+			mu.Lock()
+			recordFile(entry.Name(), entry.Size())
+			mu.Unlock()
+			continue
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			sub, err := AnalyzeDirectory(childPath, verbose, opts...)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+
+			recordDir(entry.Name())
+			info.FileCount += sub.FileCount
+			info.DirCount += sub.DirCount
+			info.Size += sub.Size
+			for ext, count := range sub.FileTypes {
+				info.FileTypes[ext] += count
+			}
+			if verbose {
+				for _, f := range sub.Files {
+					info.Files = append(info.Files, filepath.Join(entry.Name(), f))
+				}
+				for _, d := range sub.Dirs {
+					info.Dirs = append(info.Dirs, filepath.Join(entry.Name(), d))
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	if verbose {
+		sort.Strings(info.Files)
+		sort.Strings(info.Dirs)
+	}
+
+	return info, nil
+}
@@ -0,0 +1,100 @@
+package analyzer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildSyntheticTree creates dirCount subdirectories under tempDir, each
+// containing filesPerDir small files, for use in parallel-walk benchmarks.
+func buildSyntheticTree(tb testing.TB, tempDir string, dirCount, filesPerDir int) {
+	tb.Helper()
+	for d := 0; d < dirCount; d++ {
+		dirPath := filepath.Join(tempDir, fmt.Sprintf("dir%d", d))
+		if err := os.MkdirAll(dirPath, 0755); err != nil {
+			tb.Fatalf("Failed to create directory %q: %v", dirPath, err)
+		}
+		for f := 0; f < filesPerDir; f++ {
+			filePath := filepath.Join(dirPath, fmt.Sprintf("file%d.txt", f))
+			if err := os.WriteFile(filePath, []byte("x"), 0644); err != nil {
+				tb.Fatalf("Failed to create file %q: %v", filePath, err)
+			}
+		}
+	}
+}
+
+func TestAnalyzeDirectoryParallelMatchesSequential(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "parallel-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	buildSyntheticTree(t, tempDir, 10, 20)
+
+	sequential, err := AnalyzeDirectory(tempDir, true)
+	if err != nil {
+		t.Fatalf("AnalyzeDirectory failed: %v", err)
+	}
+
+	parallel, err := AnalyzeDirectoryParallel(tempDir, true, 4)
+	if err != nil {
+		t.Fatalf("AnalyzeDirectoryParallel failed: %v", err)
+	}
+
+	if parallel.FileCount != sequential.FileCount {
+		t.Errorf("FileCount = %d, expected %d", parallel.FileCount, sequential.FileCount)
+	}
+	if parallel.DirCount != sequential.DirCount {
+		t.Errorf("DirCount = %d, expected %d", parallel.DirCount, sequential.DirCount)
+	}
+	if parallel.Size != sequential.Size {
+		t.Errorf("Size = %d, expected %d", parallel.Size, sequential.Size)
+	}
+	if len(parallel.Files) != len(sequential.Files) {
+		t.Fatalf("len(Files) = %d, expected %d", len(parallel.Files), len(sequential.Files))
+	}
+	for i := range parallel.Files {
+		if parallel.Files[i] != sequential.Files[i] {
+			t.Errorf("Files[%d] = %q, expected %q (both should be sorted identically)", i, parallel.Files[i], sequential.Files[i])
+			break
+		}
+	}
+}
+
+// BenchmarkAnalyzeDirectorySequential and BenchmarkAnalyzeDirectoryParallel
+// compare single-threaded vs parallel walk times over a synthetic tree with
+// 100 subdirectories of 1,000 files each (100,000 files total).
+func BenchmarkAnalyzeDirectorySequential(b *testing.B) {
+	tempDir, err := os.MkdirTemp("", "bench-sequential-")
+	if err != nil {
+		b.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	buildSyntheticTree(b, tempDir, 100, 1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := AnalyzeDirectory(tempDir, false); err != nil {
+			b.Fatalf("AnalyzeDirectory failed: %v", err)
+		}
+	}
+}
+
+func BenchmarkAnalyzeDirectoryParallel(b *testing.B) {
+	tempDir, err := os.MkdirTemp("", "bench-parallel-")
+	if err != nil {
+		b.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	buildSyntheticTree(b, tempDir, 100, 1000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := AnalyzeDirectoryParallel(tempDir, false, 0); err != nil {
+			b.Fatalf("AnalyzeDirectoryParallel failed: %v", err)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kanywst/scallop/internal/filter"
+	yaml "gopkg.in/yaml.v3"
+)
+
+// Policy controls which findings a security scan reports, as opposed to
+// pkg/config.Config, which controls how the scan itself runs. It is loaded
+// per-scan from a YAML file rather than layered from CLI flags/environment
+// the way Config is, since exclude/suppression rules are expected to live
+// alongside the images or repos they apply to.
+type Policy struct {
+	// ExcludePaths, ExcludeExtensions, ExcludeStrings, and IncludePaths are
+	// passed straight through to filter.New; see its doc comment for the
+	// "{sep}" and "**" pattern syntax.
+	ExcludePaths      []string `yaml:"exclude_paths"`
+	ExcludeExtensions []string `yaml:"exclude_extensions"`
+	ExcludeStrings    []string `yaml:"exclude_strings"`
+	IncludePaths      []string `yaml:"include_paths"`
+
+	// SeverityOverrides rewrites the severity of a finding matched by
+	// suppressionKey, e.g. to downgrade a known-low-risk finding without
+	// dropping it from the report entirely.
+	SeverityOverrides map[string]string `yaml:"severity_overrides"`
+	// Suppressions drops a finding matched by suppressionKey entirely. The
+	// map value is a free-form reason, recorded only for the policy
+	// author's own documentation; AnalyzeSecurityWithPolicy does not
+	// interpret it.
+	Suppressions map[string]string `yaml:"suppressions"`
+
+	matcher *filter.Matcher
+}
+
+// LoadPolicy reads a YAML policy file at path and builds the filter.Matcher
+// its exclude/include rules require. If a .scallopignore file exists
+// alongside path, its patterns are loaded too, layering on top of
+// ExcludePaths.
+func LoadPolicy(path string) (*Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy file: %v", err)
+	}
+
+	p := &Policy{}
+	if err := yaml.Unmarshal(data, p); err != nil {
+		return nil, fmt.Errorf("failed to parse policy file: %v", err)
+	}
+
+	p.matcher = filter.New(p.ExcludePaths, p.ExcludeExtensions, p.ExcludeStrings, p.IncludePaths)
+	if err := p.matcher.LoadIgnoreFile(filepath.Dir(path), ".scallopignore"); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// suppressionKey identifies a finding for SeverityOverrides/Suppressions
+// lookup, independent of the line number in issue.Path: a sha256 hash of
+// its type, path (with any trailing ":<line>" stripped), and description.
+// Stripping the line number keeps a suppression rule stable across scans
+// where unrelated lines shift a secret's reported line number.
+func suppressionKey(issue SecurityIssue) string {
+	path := strings.SplitN(issue.Path, ":", 2)[0]
+	sum := sha256.Sum256([]byte(issue.Type + "|" + path + "|" + issue.Description))
+	return hex.EncodeToString(sum[:])
+}
+
+// PolicyReport is the result of AnalyzeSecurityWithPolicy: the filtered
+// SecurityResult, plus bookkeeping on how the policy changed it so an
+// operator can tell a quiet scan from a suppressed one.
+type PolicyReport struct {
+	Result          *SecurityResult `json:"result"`
+	SuppressedCount int             `json:"suppressedCount"`
+	// RuleHits counts, by suppressionKey, how many times each Suppressions
+	// or SeverityOverrides entry actually matched a finding in this scan.
+	RuleHits map[string]int `json:"ruleHits"`
+}
+
+// AnalyzeSecurityWithPolicy runs the same checks as AnalyzeSecurity, except
+// p's exclude/include rules are applied at the walker level (so excluded
+// files are never even read), and its Suppressions and SeverityOverrides
+// are then applied to whatever findings remain: a suppressed finding is
+// dropped and counted in SuppressedCount, and an overridden finding has its
+// Severity rewritten, both before severity totals are recomputed.
+func AnalyzeSecurityWithPolicy(imagePath string, p *Policy, opts ...Option) (*PolicyReport, error) {
+	o := newOptions(opts...)
+
+	db := o.vulnDB
+	if db == nil {
+		db = defaultVulnerabilityDB()
+	}
+	if o.vulnDBCacheTTL > 0 {
+		db = newCachingVulnDB(db, o.vulnDBCacheTTL)
+	}
+
+	sensitiveFiles, err := findSensitiveFiles(o.fs, imagePath, p.matcher)
+	if err != nil {
+		return nil, err
+	}
+	secrets, err := findHardcodedSecrets(o.fs, imagePath, p.matcher, o.verifiers...)
+	if err != nil {
+		return nil, err
+	}
+	vulnPackages, err := ScanVulnerabilities(imagePath, db)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []SecurityIssue
+	all = append(all, sensitiveFiles...)
+	all = append(all, secrets...)
+	all = append(all, vulnPackages...)
+
+	result := &SecurityResult{}
+	report := &PolicyReport{RuleHits: make(map[string]int)}
+
+	for _, issue := range all {
+		path := strings.SplitN(issue.Path, ":", 2)[0]
+		if p.matcher.ExcludesPath(path) {
+			continue
+		}
+
+		key := suppressionKey(issue)
+		if _, ok := p.Suppressions[key]; ok {
+			report.RuleHits[key]++
+			report.SuppressedCount++
+			continue
+		}
+		if severity, ok := p.SeverityOverrides[key]; ok {
+			issue.Severity = severity
+			report.RuleHits[key]++
+		}
+
+		result.Issues = append(result.Issues, issue)
+	}
+
+	for _, issue := range result.Issues {
+		switch issue.Severity {
+		case "HIGH", "CRITICAL":
+			result.HighSeverity++
+		case "MEDIUM":
+			result.MediumSeverity++
+		case "LOW":
+			result.LowSeverity++
+		}
+	}
+	result.TotalIssues = len(result.Issues)
+	report.Result = result
+
+	return report, nil
+}
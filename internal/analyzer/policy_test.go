@@ -0,0 +1,218 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kanywst/scallop/internal/filter"
+	"github.com/spf13/afero"
+)
+
+func TestLoadPolicy(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "policy-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	policyYAML := `
+exclude_paths:
+  - "**/vendor/**"
+exclude_extensions:
+  - ".md"
+include_paths:
+  - "**/keep.md"
+`
+	policyPath := filepath.Join(tempDir, "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0644); err != nil {
+		t.Fatalf("Failed to write policy file: %v", err)
+	}
+
+	p, err := LoadPolicy(policyPath)
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+
+	if !p.matcher.ExcludesPath("app/vendor/lib.js") {
+		t.Errorf("expected exclude_paths to exclude a vendored file")
+	}
+	if !p.matcher.ExcludesPath("README.md") {
+		t.Errorf("expected exclude_extensions to exclude a .md file")
+	}
+	if p.matcher.ExcludesPath("docs/keep.md") {
+		t.Errorf("expected include_paths to rescue keep.md")
+	}
+}
+
+func TestAnalyzeSecurityWithPolicyUsesGivenVulnDB(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "policy-vulndb-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	packageJSON := `{"dependencies": {"vulnerable-pkg": "1.0.0"}}`
+	if err := os.WriteFile(filepath.Join(tempDir, "package.json"), []byte(packageJSON), 0644); err != nil {
+		t.Fatalf("Failed to write package.json: %v", err)
+	}
+
+	p := &Policy{matcher: filter.New(nil, nil, nil, nil)}
+	stub := &countingVulnDB{}
+
+	report, err := AnalyzeSecurityWithPolicy(tempDir, p, WithVulnDB(stub))
+	if err != nil {
+		t.Fatalf("AnalyzeSecurityWithPolicy failed: %v", err)
+	}
+
+	if stub.queried == 0 {
+		t.Error("expected AnalyzeSecurityWithPolicy to query the VulnerabilityDB given via WithVulnDB")
+	}
+
+	var found bool
+	for _, issue := range report.Result.Issues {
+		if issue.Type == "VULNERABLE_PACKAGE" {
+			found = true
+		}
+	}
+	if !found {
+		t.Error("expected a VULNERABLE_PACKAGE issue from the stub db's result")
+	}
+}
+
+func TestAnalyzeSecurityWithPolicyExcludesAndSuppresses(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "policy-scan-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	files := []struct {
+		path    string
+		content string
+	}{
+		{".env", "DB_PASSWORD=secret123"},
+		{"vendor/.env", "DB_PASSWORD=secret123"},
+		{"config.js", "const password = 'supersecretpassword';"},
+	}
+	for _, f := range files {
+		dirPath := filepath.Dir(filepath.Join(tempDir, f.path))
+		if err := os.MkdirAll(dirPath, 0755); err != nil {
+			t.Fatalf("Failed to create directory %q: %v", dirPath, err)
+		}
+		if err := os.WriteFile(filepath.Join(tempDir, f.path), []byte(f.content), 0644); err != nil {
+			t.Fatalf("Failed to create file %q: %v", f.path, err)
+		}
+	}
+
+	// First pass with no suppressions/overrides, to discover the
+	// suppressionKey for the .env finding.
+	basePolicy := &Policy{}
+	basePolicy.matcher = nil
+	report, err := AnalyzeSecurityWithPolicy(tempDir, basePolicy, WithFS(afero.NewOsFs()))
+	if err != nil {
+		t.Fatalf("AnalyzeSecurityWithPolicy failed: %v", err)
+	}
+
+	var envKey string
+	for _, issue := range report.Result.Issues {
+		if issue.Type == "SENSITIVE_FILE" && issue.Path == ".env" {
+			envKey = suppressionKey(issue)
+		}
+	}
+	if envKey == "" {
+		t.Fatalf("expected to find a SENSITIVE_FILE issue for .env")
+	}
+
+	// Second pass: exclude vendor/** (so the duplicated vendored .env
+	// doesn't count twice) and suppress the root .env finding by key.
+	p, err := LoadPolicy(writePolicyFile(t, tempDir, `
+exclude_paths:
+  - "**/vendor/**"
+`))
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+	p.Suppressions = map[string]string{envKey: "known test fixture"}
+
+	report, err = AnalyzeSecurityWithPolicy(tempDir, p, WithFS(afero.NewOsFs()))
+	if err != nil {
+		t.Fatalf("AnalyzeSecurityWithPolicy failed: %v", err)
+	}
+
+	if report.SuppressedCount != 1 {
+		t.Errorf("expected SuppressedCount 1, got %d", report.SuppressedCount)
+	}
+	if report.RuleHits[envKey] != 1 {
+		t.Errorf("expected RuleHits[envKey] 1, got %d", report.RuleHits[envKey])
+	}
+	for _, issue := range report.Result.Issues {
+		if issue.Path == ".env" || issue.Path == "vendor/.env" {
+			t.Errorf("expected .env and vendor/.env to be absent, found %q", issue.Path)
+		}
+	}
+}
+
+func TestAnalyzeSecurityWithPolicySeverityOverride(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "policy-override-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if err := os.WriteFile(filepath.Join(tempDir, ".env"), []byte("DB_PASSWORD=secret123"), 0644); err != nil {
+		t.Fatalf("Failed to create .env: %v", err)
+	}
+
+	p, err := LoadPolicy(writePolicyFile(t, tempDir, ""))
+	if err != nil {
+		t.Fatalf("LoadPolicy failed: %v", err)
+	}
+
+	report, err := AnalyzeSecurityWithPolicy(tempDir, p, WithFS(afero.NewOsFs()))
+	if err != nil {
+		t.Fatalf("AnalyzeSecurityWithPolicy failed: %v", err)
+	}
+	var key string
+	for _, issue := range report.Result.Issues {
+		if issue.Path == ".env" {
+			key = suppressionKey(issue)
+		}
+	}
+	if key == "" {
+		t.Fatalf("expected to find a SENSITIVE_FILE issue for .env")
+	}
+
+	p.SeverityOverrides = map[string]string{key: "LOW"}
+	report, err = AnalyzeSecurityWithPolicy(tempDir, p, WithFS(afero.NewOsFs()))
+	if err != nil {
+		t.Fatalf("AnalyzeSecurityWithPolicy failed: %v", err)
+	}
+
+	found := false
+	for _, issue := range report.Result.Issues {
+		if issue.Path == ".env" {
+			found = true
+			if issue.Severity != "LOW" {
+				t.Errorf("expected overridden severity LOW, got %q", issue.Severity)
+			}
+		}
+	}
+	if !found {
+		t.Errorf("expected .env issue to still be present, just downgraded")
+	}
+	if report.RuleHits[key] != 1 {
+		t.Errorf("expected RuleHits[key] 1, got %d", report.RuleHits[key])
+	}
+}
+
+// writePolicyFile writes content as a policy YAML file in dir and returns
+// its path.
+func writePolicyFile(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "policy.yaml")
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to write policy file: %v", err)
+	}
+	return path
+}
@@ -0,0 +1,329 @@
+package analyzer
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/kanywst/scallop/internal/filter"
+)
+
+// secretRule is one entry of curatedSecretRules: a provider-specific pattern
+// plus the metadata findHardcodedSecrets needs to report it and, if a
+// matching Verifier is supplied, confirm it live.
+type secretRule struct {
+	id        string // stable identifier; matched against Verifier.RuleID
+	ecosystem string
+	severity  string
+	desc      string
+	regex     *regexp.Regexp
+	// keyword, if non-empty, is a lowercase literal substring that must be
+	// present in a line before regex is even attempted. It lets
+	// scanLinesForSecrets skip the regexp engine entirely on lines that
+	// plainly can't match, which matters on large, mostly-irrelevant files
+	// where a strings.Contains check against an already-lowercased line is
+	// far cheaper than running every rule's regex. Rules whose regex
+	// already starts with an unanchored literal (e.g. "AKIA") gain little
+	// from this and may leave it empty.
+	keyword string
+}
+
+// matches reports whether line (whose lowercase form is lowerLine, computed
+// once per line rather than once per rule) could satisfy r, checking the
+// cheap keyword pre-filter before falling through to the regex.
+func (r secretRule) matches(line, lowerLine string) string {
+	if r.keyword != "" && !strings.Contains(lowerLine, r.keyword) {
+		return ""
+	}
+	return r.regex.FindString(line)
+}
+
+// curatedSecretRules extends the generic "name = value" checks in
+// findHardcodedSecrets with provider-specific formats that don't require a
+// recognizable variable name next to them: cloud and SaaS API tokens,
+// private key material, and JWTs. It is not exhaustive, but covers the
+// providers most commonly leaked into container images.
+var curatedSecretRules = []secretRule{
+	{"aws-access-key-id", "aws", "HIGH", "AWS access key ID", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`), ""},
+	{"aws-secret-access-key", "aws", "HIGH", "Possible AWS secret access key", regexp.MustCompile(`(?i)aws_?secret[a-z_]*\s*[:=]\s*['"][A-Za-z0-9/+=]{40}['"]`), "secret"},
+	{"github-pat", "github", "HIGH", "GitHub personal access token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`), ""},
+	{"gitlab-pat", "gitlab", "HIGH", "GitLab personal access token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20}\b`), ""},
+	{"stripe-live-key", "stripe", "HIGH", "Stripe live secret key", regexp.MustCompile(`\bsk_live_[A-Za-z0-9]{24,}\b`), ""},
+	{"stripe-restricted-key", "stripe", "HIGH", "Stripe live restricted key", regexp.MustCompile(`\brk_live_[A-Za-z0-9]{24,}\b`), ""},
+	{"slack-token", "slack", "HIGH", "Slack token", regexp.MustCompile(`\bxox[baprs]-[A-Za-z0-9-]{10,}\b`), ""},
+	{"google-api-key", "google", "MEDIUM", "Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`), ""},
+	{"gcp-service-account", "google", "HIGH", "GCP service account key JSON", regexp.MustCompile(`"type"\s*:\s*"service_account"`), "service_account"},
+	{"azure-storage-key", "azure", "HIGH", "Possible Azure storage account key", regexp.MustCompile(`(?i)AccountKey\s*=\s*[A-Za-z0-9+/]{86}==`), "accountkey"},
+	{"azure-sas-token", "azure", "MEDIUM", "Possible Azure shared access signature token", regexp.MustCompile(`(?i)sig=[A-Za-z0-9%]{20,}`), "sig="},
+	{"heroku-api-key", "heroku", "MEDIUM", "Possible Heroku API key", regexp.MustCompile(`(?i)heroku[a-z_]*key\s*[:=]\s*['"][0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}['"]`), "heroku"},
+	{"twilio-api-key", "twilio", "HIGH", "Twilio API key", regexp.MustCompile(`\bSK[0-9a-fA-F]{32}\b`), ""},
+	{"sendgrid-api-key", "sendgrid", "HIGH", "SendGrid API key", regexp.MustCompile(`\bSG\.[A-Za-z0-9_-]{22}\.[A-Za-z0-9_-]{43}\b`), "sg."},
+	{"npm-token", "npm", "HIGH", "npm access token", regexp.MustCompile(`\bnpm_[A-Za-z0-9]{36}\b`), "npm_"},
+	{"pem-private-key", "pem", "HIGH", "PEM private key block", regexp.MustCompile(`-----BEGIN (RSA |EC |OPENSSH |DSA |)PRIVATE KEY-----`), "private key"},
+	{"jwt", "jwt", "MEDIUM", "JSON Web Token", regexp.MustCompile(`\bey[A-Za-z0-9_-]{10,}\.ey[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`), ""},
+}
+
+// secretCandidateRe pulls out quoted strings and long bare tokens from a
+// line so entropyCandidates can score them, independent of whether any
+// known pattern matched.
+var secretCandidateRe = regexp.MustCompile(`["']([A-Za-z0-9+/=_.-]{20,})["']|\b([A-Za-z0-9+/]{25,})\b`)
+
+// defaultEntropyThreshold is the Shannon entropy, in bits per character, a
+// candidate string of at least minEntropyLength characters must reach to be
+// flagged as a likely secret when no other pattern matched it.
+const (
+	defaultEntropyThreshold = 4.5
+	minEntropyLength        = 20
+)
+
+// shannonEntropy computes the Shannon entropy of s in bits per character.
+func shannonEntropy(s string) float64 {
+	if s == "" {
+		return 0
+	}
+
+	counts := make(map[rune]int)
+	for _, r := range s {
+		counts[r]++
+	}
+
+	length := float64(len(s))
+	var entropy float64
+	for _, count := range counts {
+		p := float64(count) / length
+		entropy -= p * math.Log2(p)
+	}
+	return entropy
+}
+
+// entropyCandidates extracts every quoted string / long bare token on line
+// whose Shannon entropy meets threshold, for lines that no curated or
+// generic pattern already matched.
+func entropyCandidates(line string, threshold float64) []string {
+	var candidates []string
+	for _, m := range secretCandidateRe.FindAllStringSubmatch(line, -1) {
+		candidate := m[1]
+		if candidate == "" {
+			candidate = m[2]
+		}
+		if len(candidate) < minEntropyLength {
+			continue
+		}
+		if shannonEntropy(candidate) >= threshold {
+			candidates = append(candidates, candidate)
+		}
+	}
+	return candidates
+}
+
+// allSecretRules is genericSecretRules (in security.go) followed by
+// curatedSecretRules, combined once so scanLinesForSecrets doesn't rebuild
+// the list on every file it scans.
+var allSecretRules = append(append([]secretRule{}, genericSecretRules...), curatedSecretRules...)
+
+// maxScanLineSize bounds how long a single line scanLinesForSecrets will
+// buffer before giving up on it. bufio.Scanner's default (bufio.MaxScanTokenSize,
+// 64KiB) is too easy for a minified JS bundle or a one-line JSON blob -
+// both common in real container images - to exceed, which would otherwise
+// make scanner.Scan() fail and silently stop scanning the rest of the file.
+// 1MiB comfortably covers those cases while still bounding memory use per
+// line, so a pathological file can't make this allocate without limit.
+const maxScanLineSize = 1 << 20
+
+// scanLinesForSecrets runs allSecretRules, and for lines none of them
+// match, the entropy check (entropyCandidates), against every line r
+// yields. relPath is used only to build each returned issue's Path as
+// "<relPath>:<line>"; it is the caller's job to make relPath meaningful,
+// whether that's a path relative to an extracted image or a path inside a
+// layer tarball. matcher, if non-nil, drops a line that contains one of its
+// exclude_strings before either check runs, so a known-safe placeholder
+// (e.g. a fixture value) is never reported.
+func scanLinesForSecrets(r io.Reader, relPath string, cache *verifierCache, verifiers []Verifier, matcher *filter.Matcher) []SecurityIssue {
+	var issues []SecurityIssue
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxScanLineSize)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := scanner.Text()
+
+		if matcher.ExcludesString(line) {
+			continue
+		}
+
+		lowerLine := strings.ToLower(line)
+
+		matched := false
+		for _, rule := range allSecretRules {
+			if m := rule.matches(line, lowerLine); m != "" {
+				matched = true
+				severity := upgradeIfVerified(cache, verifiers, rule.id, m, rule.severity)
+				issues = append(issues, SecurityIssue{
+					Type:        "HARDCODED_SECRET",
+					Path:        fmt.Sprintf("%s:%d", relPath, lineNum),
+					Description: rule.desc,
+					Severity:    severity,
+				})
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+
+		for _, candidate := range entropyCandidates(line, defaultEntropyThreshold) {
+			issues = append(issues, SecurityIssue{
+				Type:        "HARDCODED_SECRET",
+				Path:        fmt.Sprintf("%s:%d", relPath, lineNum),
+				Description: fmt.Sprintf("High-entropy string that may be a hardcoded secret (%.1f bits/char)", shannonEntropy(candidate)),
+				Severity:    "MEDIUM",
+			})
+		}
+	}
+
+	return issues
+}
+
+// Verifier makes a low-impact live call to confirm a detected candidate
+// secret is still active, so findHardcodedSecrets can upgrade its severity
+// from HIGH to CRITICAL instead of reporting every historical or rotated
+// credential at the same level. Verification is opt-in: it is only
+// attempted for rules with a matching RuleID, and only when the caller
+// supplies verifiers via WithSecretVerifiers, since it makes outbound
+// network calls using the candidate's own value.
+type Verifier interface {
+	// RuleID is the secretRule.id this verifier can check, e.g.
+	// "github-pat" or "aws-access-key-id".
+	RuleID() string
+	// Verify reports whether secret is still a valid, live credential.
+	Verify(secret string) (bool, error)
+}
+
+// verifierCache memoizes Verify results by a hash of the credential so the
+// same secret appearing on multiple lines, or across multiple files, only
+// triggers one live call.
+type verifierCache struct {
+	results map[string]bool
+}
+
+func newVerifierCache() *verifierCache {
+	return &verifierCache{results: make(map[string]bool)}
+}
+
+func (c *verifierCache) verify(v Verifier, secret string) (bool, error) {
+	key := hashSecret(secret)
+	if valid, ok := c.results[key]; ok {
+		return valid, nil
+	}
+	valid, err := v.Verify(secret)
+	if err != nil {
+		return false, err
+	}
+	c.results[key] = valid
+	return valid, nil
+}
+
+func hashSecret(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// verifierFor returns the first of verifiers whose RuleID matches ruleID.
+func verifierFor(verifiers []Verifier, ruleID string) Verifier {
+	for _, v := range verifiers {
+		if v.RuleID() == ruleID {
+			return v
+		}
+	}
+	return nil
+}
+
+// upgradeIfVerified re-checks a detected secret against a matching verifier
+// and escalates severity to CRITICAL when the verifier confirms it is still
+// live. Verification errors (e.g. the provider's API is unreachable) are
+// swallowed: a failed verification attempt should not hide a real finding.
+func upgradeIfVerified(cache *verifierCache, verifiers []Verifier, ruleID, secret, severity string) string {
+	v := verifierFor(verifiers, ruleID)
+	if v == nil {
+		return severity
+	}
+	if valid, err := cache.verify(v, secret); err == nil && valid {
+		return "CRITICAL"
+	}
+	return severity
+}
+
+// githubTokenVerifier confirms a GitHub personal access token is still
+// valid by calling GET /user, the same low-impact, read-only endpoint
+// GitHub's own token-scanning partner program uses for this purpose.
+type githubTokenVerifier struct {
+	client *http.Client
+}
+
+// NewGitHubTokenVerifier returns a Verifier for the "github-pat" rule that
+// confirms a token via the GitHub API. It makes one authenticated GET
+// request per unique candidate token.
+func NewGitHubTokenVerifier() Verifier {
+	return githubTokenVerifier{client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (githubTokenVerifier) RuleID() string { return "github-pat" }
+
+func (v githubTokenVerifier) Verify(secret string) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://api.github.com/user", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "token "+secret)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	return resp.StatusCode == http.StatusOK, nil
+}
+
+// slackTokenVerifier confirms a Slack token is still valid by calling
+// auth.test, Slack's own no-op credential-check endpoint.
+type slackTokenVerifier struct {
+	client *http.Client
+}
+
+// NewSlackTokenVerifier returns a Verifier for the "slack-token" rule.
+func NewSlackTokenVerifier() Verifier {
+	return slackTokenVerifier{client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+func (slackTokenVerifier) RuleID() string { return "slack-token" }
+
+func (v slackTokenVerifier) Verify(secret string) (bool, error) {
+	req, err := http.NewRequest(http.MethodGet, "https://slack.com/api/auth.test", nil)
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Authorization", "Bearer "+secret)
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		OK bool `json:"ok"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return false, fmt.Errorf("failed to decode Slack auth.test response: %v", err)
+	}
+	return body.OK, nil
+}
@@ -1,13 +1,14 @@
 package analyzer
 
 import (
-	"bufio"
-	"encoding/json"
-	"fmt"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+
+	"github.com/kanywst/scallop/internal/filter"
+	"github.com/kanywst/scallop/internal/sbom"
+	"github.com/spf13/afero"
 )
 
 // SecurityIssue represents a security issue found in the Docker image
@@ -16,6 +17,29 @@ type SecurityIssue struct {
 	Path        string `json:"path"`
 	Description string `json:"description"`
 	Severity    string `json:"severity"`
+	// CVEID, CVSSScore, FixedVersion, and References are populated for
+	// VULNERABLE_PACKAGE issues raised by a VulnerabilityDB-backed scan
+	// (see AnalyzeSecurityWithDB); they are empty for the pattern-based
+	// checks below, which have no advisory to cite.
+	CVEID        string   `json:"cveId,omitempty"`
+	CVSSScore    string   `json:"cvssScore,omitempty"`
+	FixedVersion string   `json:"fixedVersion,omitempty"`
+	References   []string `json:"references,omitempty"`
+	// LayerIndex, LayerDiffID, and Command are populated by
+	// AnalyzeSecurityPerLayer, which scans each layer independently instead
+	// of the merged rootfs; they attribute an issue to the layer (by
+	// position and digest) and Dockerfile instruction that introduced it.
+	// They are the zero value for issues from AnalyzeSecurity/
+	// AnalyzeSecurityWithDB, which don't track per-layer origin.
+	LayerIndex  int    `json:"layerIndex,omitempty"`
+	LayerDiffID string `json:"layerDiffId,omitempty"`
+	Command     string `json:"command,omitempty"`
+	// BOMRef references the sbom.Component this VULNERABLE_PACKAGE issue was
+	// raised against (see AnalyzeSecurityWithSBOM), so a downstream tool like
+	// Dependency-Track or Grype can cross-reference the finding against the
+	// same component in SecurityResult.SBOM. It is empty for issues not
+	// raised from an enumerated package, and for SecurityResults with no SBOM.
+	BOMRef string `json:"bomRef,omitempty"`
 }
 
 // SecurityResult represents the result of a security analysis
@@ -25,39 +49,72 @@ type SecurityResult struct {
 	HighSeverity   int             `json:"highSeverity"`
 	MediumSeverity int             `json:"mediumSeverity"`
 	LowSeverity    int             `json:"lowSeverity"`
+	// SBOM is the Software Bill of Materials the vulnerable-package scan was
+	// run against, populated only by AnalyzeSecurityWithSBOM; it is nil for
+	// AnalyzeSecurity/AnalyzeSecurityWithDB/AnalyzeSecurityWithPolicy, which
+	// don't generate one.
+	SBOM *sbom.Document `json:"sbom,omitempty"`
 }
 
-// AnalyzeSecurity analyzes the security of a Docker image
-func AnalyzeSecurity(imagePath string) (*SecurityResult, error) {
+// AnalyzeSecurity analyzes the security of a Docker image. By default it
+// walks the real OS filesystem; pass WithFS to analyze an afero.Fs instead.
+// Vulnerable-package detection is backed by defaultVulnerabilityDB, which
+// prefers a cached OSV bundle (see FetchOSVBundle) and otherwise falls back
+// to a small built-in advisory list; pass WithVulnDB to use OSV.dev itself
+// or a specific offline dump instead (equivalent to calling
+// AnalyzeSecurityWithDB directly), and WithVulnDBCacheTTL to cache its
+// results for repeated lookups of the same package.
+func AnalyzeSecurity(imagePath string, opts ...Option) (*SecurityResult, error) {
+	o := newOptions(opts...)
+
+	db := o.vulnDB
+	if db == nil {
+		db = defaultVulnerabilityDB()
+	}
+	if o.vulnDBCacheTTL > 0 {
+		db = newCachingVulnDB(db, o.vulnDBCacheTTL)
+	}
+
+	return AnalyzeSecurityWithDB(imagePath, db, opts...)
+}
+
+// AnalyzeSecurityWithDB analyzes the security of a Docker image the same
+// way AnalyzeSecurity does, with vulnerable packages found by enumerating
+// installed OS packages and language manifests (EnumeratePackages) and
+// matching them against db.
+func AnalyzeSecurityWithDB(imagePath string, db VulnerabilityDB, opts ...Option) (*SecurityResult, error) {
+	o := newOptions(opts...)
 	result := &SecurityResult{}
 
 	// Check for sensitive files
-	sensitiveFiles, err := findSensitiveFiles(imagePath)
+	sensitiveFiles, err := findSensitiveFiles(o.fs, imagePath, nil)
 	if err != nil {
 		return nil, err
 	}
 	result.Issues = append(result.Issues, sensitiveFiles...)
 
 	// Check for hardcoded secrets
-	secrets, err := findHardcodedSecrets(imagePath)
+	secrets, err := findHardcodedSecrets(o.fs, imagePath, nil, o.verifiers...)
 	if err != nil {
 		return nil, err
 	}
 	result.Issues = append(result.Issues, secrets...)
 
-	// Check for vulnerable packages
-	// Note: This is a simplified implementation. In a real-world scenario,
-	// you would use a vulnerability database like CVE or a service like Trivy.
-	vulnPackages, err := findVulnerablePackages(imagePath)
+	// Check for vulnerable packages against the advisory database
+	vulnPackages, err := ScanVulnerabilities(imagePath, db)
 	if err != nil {
 		return nil, err
 	}
 	result.Issues = append(result.Issues, vulnPackages...)
 
-	// Count issues by severity
+	// Count issues by severity. CRITICAL (a verifier-confirmed live secret,
+	// see upgradeIfVerified) is counted alongside HIGH: it has no separate
+	// bucket in SecurityResult, but it is strictly more severe than HIGH, so
+	// folding it into the high-severity count is more accurate than
+	// dropping it from every bucket.
 	for _, issue := range result.Issues {
 		switch issue.Severity {
-		case "HIGH":
+		case "HIGH", "CRITICAL":
 			result.HighSeverity++
 		case "MEDIUM":
 			result.MediumSeverity++
@@ -70,37 +127,62 @@ func AnalyzeSecurity(imagePath string) (*SecurityResult, error) {
 	return result, nil
 }
 
-// findSensitiveFiles finds sensitive files in the Docker image
-func findSensitiveFiles(imagePath string) ([]SecurityIssue, error) {
-	var issues []SecurityIssue
+// AnalyzeSecurityWithSBOM analyzes the security of a Docker image the same
+// way AnalyzeSecurityWithDB does, and additionally generates a full
+// Software Bill of Materials (see sbom.Generate), attaching it as
+// SecurityResult.SBOM. Each VULNERABLE_PACKAGE issue's BOMRef resolves to
+// one of this SBOM's components, letting a downstream tool like
+// Dependency-Track or Grype cross-reference the two.
+func AnalyzeSecurityWithSBOM(imagePath string, db VulnerabilityDB, opts ...Option) (*SecurityResult, error) {
+	result, err := AnalyzeSecurityWithDB(imagePath, db, opts...)
+	if err != nil {
+		return nil, err
+	}
 
-	// Define patterns for sensitive files
-	sensitivePatterns := []struct {
-		pattern  string
-		severity string
-		desc     string
-	}{
-		{".env", "HIGH", "Environment file may contain sensitive information"},
-		{".aws/credentials", "HIGH", "AWS credentials file"},
-		{".ssh/id_rsa", "HIGH", "SSH private key"},
-		{".ssh/id_dsa", "HIGH", "SSH private key"},
-		{".ssh/id_ecdsa", "HIGH", "SSH private key"},
-		{".ssh/id_ed25519", "HIGH", "SSH private key"},
-		{"config.json", "MEDIUM", "Configuration file may contain sensitive information"},
-		{"credentials.json", "HIGH", "Credentials file"},
-		{"secrets.json", "HIGH", "Secrets file"},
-		{"password", "HIGH", "Password file"},
-		{".npmrc", "MEDIUM", "NPM configuration file may contain tokens"},
-		{".dockercfg", "MEDIUM", "Docker configuration file may contain credentials"},
-		{".docker/config.json", "MEDIUM", "Docker configuration file may contain credentials"},
-		{"id_rsa", "HIGH", "SSH private key"},
-		{"id_dsa", "HIGH", "SSH private key"},
-		{"id_ecdsa", "HIGH", "SSH private key"},
-		{"id_ed25519", "HIGH", "SSH private key"},
+	doc, err := sbom.Generate(imagePath)
+	if err != nil {
+		return nil, err
 	}
+	result.SBOM = doc
+
+	return result, nil
+}
+
+// sensitiveFilePatterns are the path substrings findSensitiveFiles and the
+// per-layer scan in layerscan.go both check for. It is package-level so
+// both can share a single definition.
+var sensitiveFilePatterns = []struct {
+	pattern  string
+	severity string
+	desc     string
+}{
+	{".env", "HIGH", "Environment file may contain sensitive information"},
+	{".aws/credentials", "HIGH", "AWS credentials file"},
+	{".ssh/id_rsa", "HIGH", "SSH private key"},
+	{".ssh/id_dsa", "HIGH", "SSH private key"},
+	{".ssh/id_ecdsa", "HIGH", "SSH private key"},
+	{".ssh/id_ed25519", "HIGH", "SSH private key"},
+	{"config.json", "MEDIUM", "Configuration file may contain sensitive information"},
+	{"credentials.json", "HIGH", "Credentials file"},
+	{"secrets.json", "HIGH", "Secrets file"},
+	{"password", "HIGH", "Password file"},
+	{".npmrc", "MEDIUM", "NPM configuration file may contain tokens"},
+	{".dockercfg", "MEDIUM", "Docker configuration file may contain credentials"},
+	{".docker/config.json", "MEDIUM", "Docker configuration file may contain credentials"},
+	{"id_rsa", "HIGH", "SSH private key"},
+	{"id_dsa", "HIGH", "SSH private key"},
+	{"id_ecdsa", "HIGH", "SSH private key"},
+	{"id_ed25519", "HIGH", "SSH private key"},
+}
+
+// findSensitiveFiles finds sensitive files in the Docker image. matcher, if
+// non-nil, is consulted to skip paths excluded by a Policy before they are
+// checked against sensitiveFilePatterns.
+func findSensitiveFiles(fs afero.Fs, imagePath string, matcher *filter.Matcher) ([]SecurityIssue, error) {
+	var issues []SecurityIssue
 
 	// Walk the directory tree
-	err := filepath.Walk(imagePath, func(path string, info os.FileInfo, err error) error {
+	err := afero.Walk(fs, imagePath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
@@ -116,8 +198,12 @@ func findSensitiveFiles(imagePath string) ([]SecurityIssue, error) {
 			return err
 		}
 
+		if matcher.ExcludesPath(relPath) {
+			return nil
+		}
+
 		// Check if the file matches any sensitive pattern
-		for _, pattern := range sensitivePatterns {
+		for _, pattern := range sensitiveFilePatterns {
 			if strings.Contains(strings.ToLower(relPath), pattern.pattern) {
 				issues = append(issues, SecurityIssue{
 					Type:        "SENSITIVE_FILE",
@@ -135,35 +221,43 @@ func findSensitiveFiles(imagePath string) ([]SecurityIssue, error) {
 	return issues, err
 }
 
-// findHardcodedSecrets finds hardcoded secrets in files
-func findHardcodedSecrets(imagePath string) ([]SecurityIssue, error) {
-	var issues []SecurityIssue
+// genericSecretRules are "name = value"-shaped assignments, matched by
+// variable name rather than by the value's own format. curatedSecretRules
+// (in secrets.go) catches provider-specific formats on top of these, and
+// entropyCandidates catches high-entropy strings that match neither.
+var genericSecretRules = []secretRule{
+	{"generic-password", "", "HIGH", "Hardcoded password", regexp.MustCompile(`(?i)password\s*=\s*['"]([^'"]{8,})['"]`), "assw"},
+	{"generic-password", "", "HIGH", "Hardcoded password", regexp.MustCompile(`(?i)passwd\s*=\s*['"]([^'"]{8,})['"]`), "asswd"},
+	{"generic-password", "", "HIGH", "Hardcoded password", regexp.MustCompile(`(?i)pwd\s*=\s*['"]([^'"]{8,})['"]`), "wd"},
+	{"generic-secret", "", "HIGH", "Hardcoded secret", regexp.MustCompile(`(?i)secret\s*=\s*['"]([^'"]{8,})['"]`), "ecret"},
+	{"generic-api-key", "", "HIGH", "Hardcoded API key", regexp.MustCompile(`(?i)api[_-]?key\s*=\s*['"]([^'"]{8,})['"]`), "ey"},
+	{"generic-access-key", "", "HIGH", "Hardcoded access key", regexp.MustCompile(`(?i)access[_-]?key\s*=\s*['"]([^'"]{8,})['"]`), "ccess"},
+	{"generic-token", "", "HIGH", "Hardcoded token", regexp.MustCompile(`(?i)token\s*=\s*['"]([^'"]{8,})['"]`), "oken"},
+	{"aws-access-key-id", "aws", "HIGH", "Hardcoded AWS access key", regexp.MustCompile(`(?i)aws[_-]?access[_-]?key[_-]?id\s*=\s*['"]([^'"]{16,})['"]`), "ws"},
+	{"aws-secret-access-key", "aws", "HIGH", "Hardcoded AWS secret key", regexp.MustCompile(`(?i)aws[_-]?secret[_-]?access[_-]?key\s*=\s*['"]([^'"]{16,})['"]`), "ws"},
+}
 
-	// Define patterns for hardcoded secrets
-	secretPatterns := []struct {
-		regex    *regexp.Regexp
-		severity string
-		desc     string
-	}{
-		{regexp.MustCompile(`(?i)password\s*=\s*['"]([^'"]{8,})['"]`), "HIGH", "Hardcoded password"},
-		{regexp.MustCompile(`(?i)passwd\s*=\s*['"]([^'"]{8,})['"]`), "HIGH", "Hardcoded password"},
-		{regexp.MustCompile(`(?i)pwd\s*=\s*['"]([^'"]{8,})['"]`), "HIGH", "Hardcoded password"},
-		{regexp.MustCompile(`(?i)secret\s*=\s*['"]([^'"]{8,})['"]`), "HIGH", "Hardcoded secret"},
-		{regexp.MustCompile(`(?i)api[_-]?key\s*=\s*['"]([^'"]{8,})['"]`), "HIGH", "Hardcoded API key"},
-		{regexp.MustCompile(`(?i)access[_-]?key\s*=\s*['"]([^'"]{8,})['"]`), "HIGH", "Hardcoded access key"},
-		{regexp.MustCompile(`(?i)token\s*=\s*['"]([^'"]{8,})['"]`), "HIGH", "Hardcoded token"},
-		{regexp.MustCompile(`(?i)aws[_-]?access[_-]?key[_-]?id\s*=\s*['"]([^'"]{16,})['"]`), "HIGH", "Hardcoded AWS access key"},
-		{regexp.MustCompile(`(?i)aws[_-]?secret[_-]?access[_-]?key\s*=\s*['"]([^'"]{16,})['"]`), "HIGH", "Hardcoded AWS secret key"},
-	}
+// findHardcodedSecrets scans files for hardcoded secrets: variable-name
+// patterns (genericSecretRules), provider-specific token/key formats
+// (curatedSecretRules), and, for lines neither matches, high-entropy quoted
+// strings or tokens that look like a leaked credential even without a
+// recognizable name or prefix. Passing verifiers opts into confirming a
+// curated-rule match is still a live credential (see WithSecretVerifiers),
+// which upgrades its severity to CRITICAL; it is never attempted otherwise.
+// matcher, if non-nil, is consulted to skip excluded paths before they are
+// read, and to drop matched lines containing an excluded string.
+func findHardcodedSecrets(fs afero.Fs, imagePath string, matcher *filter.Matcher, verifiers ...Verifier) ([]SecurityIssue, error) {
+	var issues []SecurityIssue
+	cache := newVerifierCache()
 
 	// Walk the directory tree
-	err := filepath.Walk(imagePath, func(path string, info os.FileInfo, err error) error {
+	err := afero.Walk(fs, imagePath, func(path string, info os.FileInfo, err error) error {
 		if err != nil {
 			return err
 		}
 
 		// Skip directories and binary files
-		if info.IsDir() || isBinaryFile(path) {
+		if info.IsDir() || isBinaryFile(fs, path) {
 			return nil
 		}
 
@@ -173,273 +267,62 @@ func findHardcodedSecrets(imagePath string) ([]SecurityIssue, error) {
 			return err
 		}
 
+		if matcher.ExcludesPath(relPath) {
+			return nil
+		}
+
 		// Read the file
-		file, err := os.Open(path)
+		file, err := fs.Open(path)
 		if err != nil {
 			return nil // Skip files that can't be opened
 		}
 		defer file.Close()
 
-		// Scan the file line by line
-		scanner := bufio.NewScanner(file)
-		lineNum := 0
-		for scanner.Scan() {
-			lineNum++
-			line := scanner.Text()
-
-			// Check if the line matches any secret pattern
-			for _, pattern := range secretPatterns {
-				if pattern.regex.MatchString(line) {
-					issues = append(issues, SecurityIssue{
-						Type:        "HARDCODED_SECRET",
-						Path:        fmt.Sprintf("%s:%d", relPath, lineNum),
-						Description: pattern.desc,
-						Severity:    pattern.severity,
-					})
-					break
-				}
-			}
-		}
-
-		return scanner.Err()
-	})
-
-	return issues, err
-}
-
-// findVulnerablePackages finds vulnerable packages in the Docker image
-// This is a simplified implementation. In a real-world scenario,
-// you would use a vulnerability database like CVE or a service like Trivy.
-func findVulnerablePackages(imagePath string) ([]SecurityIssue, error) {
-	var issues []SecurityIssue
-
-	// Check for package files
-	packageFiles := []struct {
-		pattern string
-		check   func(string) ([]SecurityIssue, error)
-	}{
-		{"package.json", checkNodePackages},
-		{"requirements.txt", checkPythonPackages},
-		{"Gemfile.lock", checkRubyPackages},
-		{"go.mod", checkGoPackages},
-	}
-
-	// Walk the directory tree
-	err := filepath.Walk(imagePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
-
-		// Get the base name of the file
-		baseName := filepath.Base(path)
-
-		// Check if the file is a package file
-		for _, pf := range packageFiles {
-			if baseName == pf.pattern {
-				// Get the relative path
-				relPath, err := filepath.Rel(imagePath, path)
-				if err != nil {
-					return err
-				}
-
-				// Check for vulnerable packages
-				pkgIssues, err := pf.check(path)
-				if err != nil {
-					return nil // Skip files that can't be checked
-				}
-
-				// Update the path of each issue
-				for i := range pkgIssues {
-					pkgIssues[i].Path = relPath
-				}
-
-				issues = append(issues, pkgIssues...)
-			}
-		}
-
+		issues = append(issues, scanLinesForSecrets(file, relPath, cache, verifiers, matcher)...)
 		return nil
 	})
 
 	return issues, err
 }
 
-// checkNodePackages checks for vulnerable Node.js packages
-func checkNodePackages(path string) ([]SecurityIssue, error) {
-	var issues []SecurityIssue
-
-	// Read the package.json file
-	data, err := os.ReadFile(path)
-	if err != nil {
-		return nil, err
-	}
-
-	// Parse the JSON
-	var pkg struct {
-		Dependencies    map[string]string `json:"dependencies"`
-		DevDependencies map[string]string `json:"devDependencies"`
-	}
-	if err := json.Unmarshal(data, &pkg); err != nil {
-		return nil, err
-	}
-
-	// Define known vulnerable packages (simplified)
-	// In a real-world scenario, you would use a vulnerability database
-	vulnPackages := map[string]struct {
-		version    string
-		severity   string
-		desc       string
-		fixVersion string
-	}{
-		"lodash":     {"<4.17.21", "HIGH", "Prototype Pollution in lodash", ">=4.17.21"},
-		"minimist":   {"<1.2.6", "HIGH", "Prototype Pollution in minimist", ">=1.2.6"},
-		"node-fetch": {"<2.6.7", "HIGH", "Exposure of Sensitive Information in node-fetch", ">=2.6.7"},
-		"axios":      {"<0.21.1", "HIGH", "Server-Side Request Forgery in axios", ">=0.21.1"},
-	}
-
-	// Check dependencies
-	for name, version := range pkg.Dependencies {
-		if vuln, ok := vulnPackages[name]; ok {
-			// This is a simplified version check
-			// In a real-world scenario, you would use semver comparison
-			if strings.HasPrefix(version, "^") || strings.HasPrefix(version, "~") {
-				version = version[1:]
-			}
-			if version < vuln.version {
-				issues = append(issues, SecurityIssue{
-					Type:        "VULNERABLE_PACKAGE",
-					Path:        fmt.Sprintf("package.json: %s@%s", name, version),
-					Description: fmt.Sprintf("%s. Update to %s", vuln.desc, vuln.fixVersion),
-					Severity:    vuln.severity,
-				})
-			}
-		}
-	}
-
-	// Check dev dependencies
-	for name, version := range pkg.DevDependencies {
-		if vuln, ok := vulnPackages[name]; ok {
-			// This is a simplified version check
-			// In a real-world scenario, you would use semver comparison
-			if strings.HasPrefix(version, "^") || strings.HasPrefix(version, "~") {
-				version = version[1:]
-			}
-			if version < vuln.version {
-				issues = append(issues, SecurityIssue{
-					Type:        "VULNERABLE_PACKAGE",
-					Path:        fmt.Sprintf("package.json: %s@%s (dev)", name, version),
-					Description: fmt.Sprintf("%s. Update to %s", vuln.desc, vuln.fixVersion),
-					Severity:    vuln.severity,
-				})
-			}
-		}
-	}
-
-	return issues, nil
+// binaryExtensions are file extensions assumed to be binary without reading
+// their content, shared by isBinaryFile and the per-layer scan in
+// layerscan.go (which, reading straight off a tar stream, has no cheap way
+// to peek content and still scan it afterwards).
+var binaryExtensions = map[string]bool{
+	".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".bmp": true,
+	".pdf": true, ".zip": true, ".tar": true, ".gz": true, ".tgz": true,
+	".rar": true, ".7z": true, ".exe": true, ".dll": true, ".so": true,
+	".dylib": true, ".bin": true, ".dat": true, ".iso": true, ".img": true,
 }
 
-// checkPythonPackages checks for vulnerable Python packages
-func checkPythonPackages(path string) ([]SecurityIssue, error) {
-	var issues []SecurityIssue
-
-	// Read the requirements.txt file
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, err
-	}
-	defer file.Close()
-
-	// Define known vulnerable packages (simplified)
-	// In a real-world scenario, you would use a vulnerability database
-	vulnPackages := map[string]struct {
-		version    string
-		severity   string
-		desc       string
-		fixVersion string
-	}{
-		"django":   {"<3.2.14", "HIGH", "SQL Injection in Django", ">=3.2.14"},
-		"flask":    {"<2.0.1", "MEDIUM", "Open Redirect in Flask", ">=2.0.1"},
-		"requests": {"<2.26.0", "MEDIUM", "CRLF Injection in Requests", ">=2.26.0"},
-		"pillow":   {"<9.0.0", "HIGH", "Buffer Overflow in Pillow", ">=9.0.0"},
-	}
-
-	// Scan the file line by line
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// Skip comments and empty lines
-		if strings.HasPrefix(line, "#") || strings.TrimSpace(line) == "" {
-			continue
-		}
-
-		// Parse the package name and version
-		parts := strings.Split(line, "==")
-		if len(parts) != 2 {
-			continue
-		}
-		name := strings.TrimSpace(parts[0])
-		version := strings.TrimSpace(parts[1])
-
-		// Check if the package is vulnerable
-		if vuln, ok := vulnPackages[name]; ok {
-			// This is a simplified version check
-			// In a real-world scenario, you would use semver comparison
-			if version < vuln.version {
-				issues = append(issues, SecurityIssue{
-					Type:        "VULNERABLE_PACKAGE",
-					Path:        fmt.Sprintf("requirements.txt: %s==%s", name, version),
-					Description: fmt.Sprintf("%s. Update to %s", vuln.desc, vuln.fixVersion),
-					Severity:    vuln.severity,
-				})
-			}
-		}
-	}
-
-	return issues, scanner.Err()
-}
-
-// checkRubyPackages checks for vulnerable Ruby packages
-func checkRubyPackages(path string) ([]SecurityIssue, error) {
-	// Simplified implementation
-	// In a real-world scenario, you would parse the Gemfile.lock and check against a vulnerability database
-	return []SecurityIssue{}, nil
+// isBinaryExt reports whether path's extension is assumed to be binary,
+// without reading its content.
+func isBinaryExt(path string) bool {
+	return binaryExtensions[strings.ToLower(filepath.Ext(path))]
 }
 
-// checkGoPackages checks for vulnerable Go packages
-func checkGoPackages(path string) ([]SecurityIssue, error) {
-	// Simplified implementation
-	// In a real-world scenario, you would parse the go.mod file and check against a vulnerability database
-	return []SecurityIssue{}, nil
-}
+// binarySniffLen is how many leading bytes of a file isBinaryFile reads to
+// look for a NUL byte. 8KiB is large enough to reach past a text file's
+// BOM, shebang line, or leading whitespace/comments into its actual body,
+// while still being a single small, bounded read.
+const binarySniffLen = 8192
 
 // isBinaryFile checks if a file is binary
-func isBinaryFile(path string) bool {
-	// Check file extension
-	ext := strings.ToLower(filepath.Ext(path))
-	binaryExts := map[string]bool{
-		".jpg": true, ".jpeg": true, ".png": true, ".gif": true, ".bmp": true,
-		".pdf": true, ".zip": true, ".tar": true, ".gz": true, ".tgz": true,
-		".rar": true, ".7z": true, ".exe": true, ".dll": true, ".so": true,
-		".dylib": true, ".bin": true, ".dat": true, ".iso": true, ".img": true,
-	}
-	if binaryExts[ext] {
+func isBinaryFile(fs afero.Fs, path string) bool {
+	if isBinaryExt(path) {
 		return true
 	}
 
 	// Check file content (read first few bytes)
-	file, err := os.Open(path)
+	file, err := fs.Open(path)
 	if err != nil {
 		return false
 	}
 	defer file.Close()
 
-	// Read the first 512 bytes
-	buf := make([]byte, 512)
+	// Read the first binarySniffLen bytes
+	buf := make([]byte, binarySniffLen)
 	n, err := file.Read(buf)
 	if err != nil || n == 0 {
 		return false
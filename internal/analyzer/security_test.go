@@ -4,6 +4,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/spf13/afero"
 )
 
 func TestAnalyzeSecurity(t *testing.T) {
@@ -157,7 +159,7 @@ func TestFindSensitiveFiles(t *testing.T) {
 	}
 
 	// Run the sensitive files check
-	issues, err := findSensitiveFiles(tempDir)
+	issues, err := findSensitiveFiles(afero.NewOsFs(), tempDir, nil)
 	if err != nil {
 		t.Fatalf("findSensitiveFiles failed: %v", err)
 	}
@@ -221,7 +223,7 @@ func TestFindHardcodedSecrets(t *testing.T) {
 	}
 
 	// Run the hardcoded secrets check
-	issues, err := findHardcodedSecrets(tempDir)
+	issues, err := findHardcodedSecrets(afero.NewOsFs(), tempDir, nil)
 	if err != nil {
 		t.Fatalf("findHardcodedSecrets failed: %v", err)
 	}
@@ -246,77 +248,27 @@ func TestFindHardcodedSecrets(t *testing.T) {
 	}
 }
 
-func TestFindVulnerablePackages(t *testing.T) {
-	// Create a temporary directory for testing
-	tempDir, err := os.MkdirTemp("", "vulnerable-packages-test-")
+func TestIsBinaryFileDetectsNulByteBeyondFirst512Bytes(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "is-binary-file-test-")
 	if err != nil {
 		t.Fatalf("Failed to create temporary directory: %v", err)
 	}
 	defer os.RemoveAll(tempDir)
 
-	// Create package files with vulnerable packages
-	packageFiles := []struct {
-		path    string
-		content string
-	}{
-		{"package.json", `{
-			"name": "test-app",
-			"version": "1.0.0",
-			"dependencies": {
-				"lodash": "4.17.20",
-				"axios": "0.21.0"
-			},
-			"devDependencies": {
-				"minimist": "1.2.5"
-			}
-		}`},
-		{"requirements.txt", "django==3.2.0\nflask==2.0.0\nrequests==2.25.0\npillow==8.0.0"},
+	// A NUL byte at offset 1000 would have been missed by a 512-byte sniff
+	// window, but should still be caught within binarySniffLen (8KiB).
+	content := make([]byte, 2000)
+	for i := range content {
+		content[i] = 'a'
 	}
+	content[1000] = 0
 
-	// Create all the test files
-	for _, file := range packageFiles {
-		dirPath := filepath.Dir(filepath.Join(tempDir, file.path))
-		if err := os.MkdirAll(dirPath, 0755); err != nil {
-			t.Fatalf("Failed to create directory %q: %v", dirPath, err)
-		}
-		if err := os.WriteFile(filepath.Join(tempDir, file.path), []byte(file.content), 0644); err != nil {
-			t.Fatalf("Failed to create file %q: %v", file.path, err)
-		}
-	}
-
-	// Run the vulnerable packages check
-	issues, err := findVulnerablePackages(tempDir)
-	if err != nil {
-		t.Fatalf("findVulnerablePackages failed: %v", err)
-	}
-
-	// Check that we found vulnerable packages
-	if len(issues) == 0 {
-		t.Errorf("Expected vulnerable packages, but found none")
-	}
-
-	// Check that all issues have the correct type
-	for _, issue := range issues {
-		if issue.Type != "VULNERABLE_PACKAGE" {
-			t.Errorf("Expected issue type VULNERABLE_PACKAGE, but got %q", issue.Type)
-		}
-	}
-
-	// Check that we found specific vulnerable packages
-	vulnerablePackages := []string{"lodash", "axios", "django", "pillow"}
-	foundPackages := make(map[string]bool)
-
-	for _, issue := range issues {
-		for _, pkg := range vulnerablePackages {
-			if issue.Path != "" && issue.Description != "" && issue.Severity != "" {
-				foundPackages[pkg] = true
-			}
-		}
+	path := filepath.Join(tempDir, "mostly-text.txt")
+	if err := os.WriteFile(path, content, 0644); err != nil {
+		t.Fatalf("Failed to write test file: %v", err)
 	}
 
-	for _, pkg := range vulnerablePackages {
-		if !foundPackages[pkg] {
-			t.Errorf("Expected to find vulnerable package %q, but it was not detected", pkg)
-		}
+	if !isBinaryFile(afero.NewOsFs(), path) {
+		t.Errorf("expected a NUL byte at offset 1000 to be detected as binary")
 	}
 }
@@ -2,9 +2,12 @@ package analyzer
 
 import (
 	"fmt"
-	"os"
 	"path/filepath"
 	"sort"
+	"strings"
+
+	"github.com/kanywst/scallop/internal/docker"
+	"github.com/spf13/afero"
 )
 
 // SizeInfo represents information about the size of a Docker image
@@ -14,12 +17,25 @@ type SizeInfo struct {
 	LargestFiles      []FileSize       `json:"largestFiles,omitempty"`
 	LargestDirs       []DirectoryInfo  `json:"largestDirs,omitempty"`
 	FileTypeBreakdown map[string]int64 `json:"fileTypeBreakdown,omitempty"`
+	// IntegrityError reports any layer whose decompressed content didn't
+	// match its expected diffID in the image config, for content-addressed
+	// (OCI/Docker v1.2) images. Empty when verification passed or was
+	// unavailable (e.g. legacy layer.tar images, or no config found).
+	IntegrityError string `json:"integrityError,omitempty"`
 }
 
-// LayerSize represents the size of a Docker image layer
+// LayerSize represents the size of a Docker image layer. Size holds the
+// on-disk (compressed) size for backwards compatibility; Command is
+// populated when the layer was loaded via LoadImage. CompressedSize and
+// UncompressedSize are populated when the layer was read from a content-
+// addressed (OCI/Docker v1.2) layout, where the blob may be gzip/zstd/xz/
+// bzip2-compressed.
 type LayerSize struct {
-	ID   string `json:"id"`
-	Size int64  `json:"size"`
+	ID               string `json:"id"`
+	Size             int64  `json:"size"`
+	CompressedSize   int64  `json:"compressedSize,omitempty"`
+	UncompressedSize int64  `json:"uncompressedSize,omitempty"`
+	Command          string `json:"command,omitempty"`
 }
 
 // FileSize represents the size of a file
@@ -28,77 +44,87 @@ type FileSize struct {
 	Size int64  `json:"size"`
 }
 
-// AnalyzeSize analyzes the size of a Docker image
-func AnalyzeSize(imagePath string) (*SizeInfo, error) {
-	info := &SizeInfo{
-		FileTypeBreakdown: make(map[string]int64),
-	}
+// AnalyzeSize analyzes the size of a Docker image. By default it walks the
+// real OS filesystem; pass WithFS to analyze an afero.Fs instead.
+func AnalyzeSize(imagePath string, opts ...Option) (*SizeInfo, error) {
+	o := newOptions(opts...)
 
-	// Get the total size of the image
-	totalSize, err := getDirSize(imagePath)
-	if err != nil {
-		return nil, err
-	}
-	info.TotalSize = totalSize
+	info := &SizeInfo{}
 
-	// Get the size of each layer
-	layerSizes, err := getLayerSizes(imagePath)
+	// Get the size of each layer, preferring the content-addressed (OCI/
+	// Docker v1.2) layout and falling back to the legacy layer.tar walk.
+	layerSizes, integrityError, err := getBlobLayerSizes(o.fs, imagePath)
 	if err != nil {
-		return nil, err
+		layerSizes, err = getLayerSizes(o.fs, imagePath)
+		if err != nil {
+			return nil, err
+		}
 	}
 	info.LayerSizes = layerSizes
+	info.IntegrityError = integrityError
 
-	// Get the largest files
-	largestFiles, err := getLargestFiles(imagePath, 10)
-	if err != nil {
-		return nil, err
-	}
-	info.LargestFiles = largestFiles
-
-	// Get the largest directories
-	largestDirs, err := GetTopDirectories(imagePath, 5)
-	if err != nil {
-		return nil, err
+	// When the caller opted in via WithVerifyDigests, treat any digest or
+	// diffID mismatch as fatal rather than just recording IntegrityError.
+	if o.verifyDigests {
+		if err := verifyLayerDigests(imagePath, opts...); err != nil {
+			return nil, err
+		}
 	}
-	info.LargestDirs = largestDirs
 
-	// Get the file type breakdown
-	fileTypeBreakdown, err := getFileTypeBreakdown(imagePath)
+	// Total size, largest files, file-type breakdown, and every directory's
+	// size all come from a single walk of the extracted tree, rather than
+	// four independent ones (see walkSize).
+	walked, err := walkSize(o.fs, imagePath, 10, true)
 	if err != nil {
 		return nil, err
 	}
-	info.FileTypeBreakdown = fileTypeBreakdown
+	info.TotalSize = walked.totalSize
+	info.LargestFiles = walked.largestFiles
+	info.FileTypeBreakdown = walked.fileTypeBreakdown
+	delete(walked.dirSizes, ".")
+	info.LargestDirs = topDirsBySize(walked.dirSizes, 5)
 
 	return info, nil
 }
 
-// getLayerSizes returns the size of each layer in a Docker image
-func getLayerSizes(imagePath string) ([]LayerSize, error) {
+// getLayerSizes returns the size of each layer in a Docker image, found by
+// walking the legacy Docker v1 "<layer-id>/layer.tar[.gz|.zst]" tree and
+// sorting by size in descending order. Docker v1 layer tarballs are
+// sometimes shipped gzip- or zstd-compressed rather than as a plain tar, so
+// each match is sniffed via detectLayerCompression and, when compressed,
+// also reports CompressedSize/UncompressedSize alongside the legacy Size
+// field. See getBlobLayerSizes for the content-addressed (OCI/Docker v1.2)
+// equivalent, which AnalyzeSize prefers when available.
+func getLayerSizes(fs afero.Fs, imagePath string) ([]LayerSize, error) {
 	var layerSizes []LayerSize
 
-	// Find all layer directories
-	layerDirs, err := filepath.Glob(filepath.Join(imagePath, "*/layer.tar"))
-	if err != nil {
-		return nil, fmt.Errorf("failed to find layer tarballs: %v", err)
-	}
-
-	// Get the size of each layer
-	for _, layerPath := range layerDirs {
-		// Get the layer ID from the directory name
-		layerDir := filepath.Dir(layerPath)
-		layerID := filepath.Base(layerDir)
-
-		// Get the size of the layer tarball
-		info, err := os.Stat(layerPath)
+	for _, pattern := range []string{"layer.tar", "layer.tar.gz", "layer.tar.zst"} {
+		layerPaths, err := afero.Glob(fs, filepath.Join(imagePath, "*", pattern))
 		if err != nil {
-			return nil, fmt.Errorf("failed to get layer size: %v", err)
+			return nil, fmt.Errorf("failed to find layer tarballs: %v", err)
 		}
 
-		// Add the layer size to the list
-		layerSizes = append(layerSizes, LayerSize{
-			ID:   layerID,
-			Size: info.Size(),
-		})
+		for _, layerPath := range layerPaths {
+			layerDir := filepath.Dir(layerPath)
+			layerID := filepath.Base(layerDir)
+
+			info, err := fs.Stat(layerPath)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get layer size: %v", err)
+			}
+
+			layerSize := LayerSize{ID: layerID, Size: info.Size()}
+
+			format, err := detectLayerCompression(fs, layerPath)
+			if err == nil && format != compressionNone {
+				if uncompressedSize, _, err := layerDiffStats(fs, layerPath); err == nil {
+					layerSize.CompressedSize = info.Size()
+					layerSize.UncompressedSize = uncompressedSize
+				}
+			}
+
+			layerSizes = append(layerSizes, layerSize)
+		}
 	}
 
 	// Sort the layers by size in descending order
@@ -109,80 +135,72 @@ func getLayerSizes(imagePath string) ([]LayerSize, error) {
 	return layerSizes, nil
 }
 
-// getLargestFiles returns the N largest files in a Docker image
-func getLargestFiles(imagePath string, count int) ([]FileSize, error) {
-	var files []FileSize
+// getBlobLayerSizes returns layer sizes for an OCI Image Layout or Docker
+// v1.2 image, keyed by content digest and preserved in manifest order. Each
+// blob is decompressed (gzip/zstd/xz/bzip2, auto-detected from its magic
+// bytes) to report both CompressedSize and UncompressedSize, and its
+// decompressed sha256 is compared against the image config's
+// rootfs.diff_ids when one is available; any mismatches are joined into the
+// returned integrity error string rather than failing the analysis. It
+// returns an error if imagePath does not use a content-addressed layout.
+func getBlobLayerSizes(fs afero.Fs, imagePath string) ([]LayerSize, string, error) {
+	layers, err := docker.ReadBlobLayout(imagePath)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Best-effort: diffID verification is skipped if no config is found.
+	cfg, _ := docker.ReadBlobConfig(imagePath)
 
-	// Walk the directory tree
-	err := filepath.Walk(imagePath, func(path string, info os.FileInfo, err error) error {
+	var integrityErrors []string
+	layerSizes := make([]LayerSize, len(layers))
+	for i, layer := range layers {
+		info, err := fs.Stat(layer.Path)
 		if err != nil {
-			return err
+			return nil, "", fmt.Errorf("failed to get layer size: %v", err)
 		}
 
-		// Skip directories
-		if info.IsDir() {
-			return nil
+		uncompressedSize, diffID, err := layerDiffStats(fs, layer.Path)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to read layer %s: %v", layer.Digest, err)
 		}
 
-		// Get the relative path
-		relPath, err := filepath.Rel(imagePath, path)
-		if err != nil {
-			return err
+		if cfg != nil && i < len(cfg.RootFS.DiffIDs) {
+			if expected := cfg.RootFS.DiffIDs[i]; expected != diffID {
+				integrityErrors = append(integrityErrors, fmt.Sprintf("layer %s: diffID mismatch: expected %s, got %s", layer.Digest, expected, diffID))
+			}
 		}
 
-		// Add the file to the list
-		files = append(files, FileSize{
-			Path: relPath,
-			Size: info.Size(),
-		})
+		layerSizes[i] = LayerSize{
+			ID:               layer.Digest,
+			Size:             info.Size(),
+			CompressedSize:   info.Size(),
+			UncompressedSize: uncompressedSize,
+		}
+	}
 
-		return nil
-	})
+	return layerSizes, strings.Join(integrityErrors, "; "), nil
+}
 
+// getLargestFiles returns the N largest files in a Docker image. It
+// delegates to walkSize, which bounds memory use via a min-heap instead of
+// collecting and sorting every file in the tree.
+func getLargestFiles(fs afero.Fs, imagePath string, count int) ([]FileSize, error) {
+	result, err := walkSize(fs, imagePath, count, true)
 	if err != nil {
 		return nil, err
 	}
-
-	// Sort the files by size in descending order
-	sort.Slice(files, func(i, j int) bool {
-		return files[i].Size > files[j].Size
-	})
-
-	// Return the top N files
-	if count > 0 && count < len(files) {
-		return files[:count], nil
-	}
-	return files, nil
+	return result.largestFiles, nil
 }
 
-// getFileTypeBreakdown returns the breakdown of file types by size
-func getFileTypeBreakdown(imagePath string) (map[string]int64, error) {
-	fileTypeBreakdown := make(map[string]int64)
-
-	// Walk the directory tree
-	err := filepath.Walk(imagePath, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
-		}
-
-		// Skip directories
-		if info.IsDir() {
-			return nil
-		}
-
-		// Get the file extension
-		ext := filepath.Ext(path)
-		if ext == "" {
-			ext = "[no extension]"
-		}
-
-		// Add the file size to the breakdown
-		fileTypeBreakdown[ext] += info.Size()
-
-		return nil
-	})
-
-	return fileTypeBreakdown, err
+// getFileTypeBreakdown returns the breakdown of file types by size. It
+// delegates to walkSize.
+func getFileTypeBreakdown(fs afero.Fs, imagePath string) (map[string]int64, error) {
+	result, err := walkSize(fs, imagePath, 0, false)
+	if err != nil {
+		return nil, err
+	}
+	return result.fileTypeBreakdown, nil
 }
 
 // FormatSize formats a size in bytes to a human-readable string
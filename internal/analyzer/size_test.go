@@ -1,10 +1,13 @@
 package analyzer
 
 import (
+	"compress/gzip"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/spf13/afero"
 )
 
 func TestAnalyzeSize(t *testing.T) {
@@ -153,7 +156,7 @@ func TestGetLayerSizes(t *testing.T) {
 	}
 
 	// Run the layer sizes check
-	layerSizes, err := getLayerSizes(tempDir)
+	layerSizes, err := getLayerSizes(afero.NewOsFs(), tempDir)
 	if err != nil {
 		t.Fatalf("getLayerSizes failed: %v", err)
 	}
@@ -182,6 +185,47 @@ func TestGetLayerSizes(t *testing.T) {
 	}
 }
 
+func TestGetLayerSizesGzipLayer(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "layer-sizes-gz-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dirPath := filepath.Join(tempDir, "layer1")
+	if err := os.MkdirAll(dirPath, 0755); err != nil {
+		t.Fatalf("Failed to create layer directory: %v", err)
+	}
+
+	var buf strings.Builder
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte("uncompressed tar content, longer than its compressed form")); err != nil {
+		t.Fatalf("Failed to write gzip content: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+
+	layerPath := filepath.Join(dirPath, "layer.tar.gz")
+	if err := os.WriteFile(layerPath, []byte(buf.String()), 0644); err != nil {
+		t.Fatalf("Failed to write layer.tar.gz: %v", err)
+	}
+
+	layerSizes, err := getLayerSizes(afero.NewOsFs(), tempDir)
+	if err != nil {
+		t.Fatalf("getLayerSizes failed: %v", err)
+	}
+	if len(layerSizes) != 1 {
+		t.Fatalf("len(layerSizes) = %d, expected 1", len(layerSizes))
+	}
+	if layerSizes[0].CompressedSize == 0 || layerSizes[0].UncompressedSize == 0 {
+		t.Errorf("layerSizes[0] = %+v, expected non-zero CompressedSize/UncompressedSize", layerSizes[0])
+	}
+	if layerSizes[0].UncompressedSize != int64(len("uncompressed tar content, longer than its compressed form")) {
+		t.Errorf("UncompressedSize = %d, expected %d", layerSizes[0].UncompressedSize, len("uncompressed tar content, longer than its compressed form"))
+	}
+}
+
 func TestGetLargestFiles(t *testing.T) {
 	// Create a temporary directory for testing
 	tempDir, err := os.MkdirTemp("", "largest-files-test-")
@@ -214,7 +258,7 @@ func TestGetLargestFiles(t *testing.T) {
 	}
 
 	// Run the largest files check with count=3
-	largestFiles, err := getLargestFiles(tempDir, 3)
+	largestFiles, err := getLargestFiles(afero.NewOsFs(), tempDir, 3)
 	if err != nil {
 		t.Fatalf("getLargestFiles failed: %v", err)
 	}
@@ -238,7 +282,7 @@ func TestGetLargestFiles(t *testing.T) {
 	}
 
 	// Run the largest files check with count=0 (should return all files)
-	allFiles, err := getLargestFiles(tempDir, 0)
+	allFiles, err := getLargestFiles(afero.NewOsFs(), tempDir, 0)
 	if err != nil {
 		t.Fatalf("getLargestFiles with count=0 failed: %v", err)
 	}
@@ -278,7 +322,7 @@ func TestGetFileTypeBreakdown(t *testing.T) {
 	}
 
 	// Run the file type breakdown check
-	breakdown, err := getFileTypeBreakdown(tempDir)
+	breakdown, err := getFileTypeBreakdown(afero.NewOsFs(), tempDir)
 	if err != nil {
 		t.Fatalf("getFileTypeBreakdown failed: %v", err)
 	}
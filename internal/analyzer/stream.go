@@ -0,0 +1,117 @@
+package analyzer
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// Entry is a single file or directory encountered while streaming a
+// directory tree, with its path relative to the scanned root.
+type Entry struct {
+	Path  string
+	Size  int64
+	IsDir bool
+}
+
+// DirLister yields directory entries page-by-page instead of walking the
+// whole tree eagerly, so callers can bound memory usage and cancel an
+// in-flight scan via ctx.
+type DirLister interface {
+	List(ctx context.Context, path string) (<-chan Entry, error)
+}
+
+// fsDirLister is the default DirLister, backed by an afero.Fs.
+type fsDirLister struct {
+	fs afero.Fs
+}
+
+// NewDirLister returns the default DirLister, which walks the given
+// afero.Fs (the real OS filesystem unless the caller substitutes one).
+func NewDirLister(fs afero.Fs) DirLister {
+	return &fsDirLister{fs: fs}
+}
+
+// List walks path and streams each entry on the returned channel. The walk
+// runs in a background goroutine and stops as soon as ctx is done; the
+// channel is always closed when the walk finishes or is cancelled.
+func (l *fsDirLister) List(ctx context.Context, path string) (<-chan Entry, error) {
+	entries := make(chan Entry)
+
+	go func() {
+		defer close(entries)
+
+		_ = afero.Walk(l.fs, path, func(p string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if p == path {
+				return nil
+			}
+
+			relPath, err := filepath.Rel(path, p)
+			if err != nil {
+				return err
+			}
+
+			entry := Entry{Path: relPath, Size: info.Size(), IsDir: info.IsDir()}
+
+			select {
+			case entries <- entry:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+	}()
+
+	return entries, nil
+}
+
+// AnalyzeDirectoryStream walks imagePath and streams each entry on the
+// returned channel, in bounded memory, so very large images (millions of
+// files) can be scanned without holding every path in RAM at once. Cancel
+// ctx to abort the scan early; the channel is closed once the walk stops.
+func AnalyzeDirectoryStream(ctx context.Context, imagePath string, opts ...Option) (<-chan Entry, error) {
+	o := newOptions(opts...)
+	lister := NewDirLister(o.fs)
+	return lister.List(ctx, imagePath)
+}
+
+// AnalyzeSizeStream computes size information for imagePath by consuming
+// AnalyzeDirectoryStream, maintaining a bounded top-K heap of the largest
+// files instead of sorting a full in-memory slice. This makes analyzing
+// multi-GB images with millions of files feasible.
+func AnalyzeSizeStream(ctx context.Context, imagePath string, topK int, opts ...Option) (*SizeInfo, error) {
+	entries, err := AnalyzeDirectoryStream(ctx, imagePath, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &SizeInfo{FileTypeBreakdown: make(map[string]int64)}
+	top := newTopKFiles(topK)
+
+	for entry := range entries {
+		if entry.IsDir {
+			continue
+		}
+
+		info.TotalSize += entry.Size
+		top.Add(FileSize{Path: entry.Path, Size: entry.Size})
+
+		ext := filepath.Ext(entry.Path)
+		if ext == "" {
+			ext = "[no extension]"
+		}
+		info.FileTypeBreakdown[ext] += entry.Size
+	}
+
+	if err := ctx.Err(); err != nil {
+		return info, err
+	}
+
+	info.LargestFiles = top.Sorted()
+	return info, nil
+}
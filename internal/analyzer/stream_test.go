@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestAnalyzeDirectoryStream(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	if err := afero.WriteFile(fs, "/image/a.txt", []byte("12345"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	if err := afero.WriteFile(fs, "/image/dir/b.txt", []byte("1234567890"), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	entries, err := AnalyzeDirectoryStream(context.Background(), "/image", WithFS(fs))
+	if err != nil {
+		t.Fatalf("AnalyzeDirectoryStream failed: %v", err)
+	}
+
+	var fileCount int
+	for entry := range entries {
+		if !entry.IsDir {
+			fileCount++
+		}
+	}
+
+	if fileCount != 2 {
+		t.Errorf("fileCount = %d, expected 2", fileCount)
+	}
+}
+
+func TestAnalyzeDirectoryStreamCancellation(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	for i := 0; i < 100; i++ {
+		if err := afero.WriteFile(fs, "/image/file.txt", []byte("x"), 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	entries, err := AnalyzeDirectoryStream(ctx, "/image", WithFS(fs))
+	if err != nil {
+		t.Fatalf("AnalyzeDirectoryStream failed: %v", err)
+	}
+
+	// Draining the channel should terminate promptly even though ctx was
+	// already cancelled before the walk started.
+	for range entries {
+	}
+}
+
+func TestAnalyzeSizeStreamTopK(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	sizes := []int{1, 5, 3, 9, 2}
+	for i, size := range sizes {
+		content := make([]byte, size)
+		if err := afero.WriteFile(fs, "/image/f"+string(rune('a'+i))+".txt", content, 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+	}
+
+	info, err := AnalyzeSizeStream(context.Background(), "/image", 2, WithFS(fs))
+	if err != nil {
+		t.Fatalf("AnalyzeSizeStream failed: %v", err)
+	}
+
+	if len(info.LargestFiles) != 2 {
+		t.Fatalf("len(LargestFiles) = %d, expected 2", len(info.LargestFiles))
+	}
+	if info.LargestFiles[0].Size != 9 || info.LargestFiles[1].Size != 5 {
+		t.Errorf("LargestFiles = %+v, expected sizes [9, 5]", info.LargestFiles)
+	}
+
+	expectedTotal := int64(1 + 5 + 3 + 9 + 2)
+	if info.TotalSize != expectedTotal {
+		t.Errorf("TotalSize = %d, expected %d", info.TotalSize, expectedTotal)
+	}
+}
@@ -0,0 +1,132 @@
+package analyzer
+
+import (
+	"archive/tar"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/kanywst/scallop/internal/docker"
+	"github.com/spf13/afero"
+)
+
+// AnalyzeSizeStreaming computes size information directly from an image's
+// layer tarballs, without ever extracting them to disk: it enumerates
+// layers via docker.ReadImageLayout (OCI Image Layout, Docker v1.2, or the
+// legacy layer.tar tree) and streams each one through archive/tar.Reader,
+// accumulating per-extension byte totals, a size-bounded top-K of the
+// largest files, and per-directory cumulative sizes rolled up to every
+// parent. Hardlinks (tar.TypeLink) reuse their target's already-counted
+// size instead of being counted again, and whiteout markers are skipped
+// entirely rather than contributing to the totals.
+func AnalyzeSizeStreaming(imagePath string, opts ...Option) (*SizeInfo, error) {
+	o := newOptions(opts...)
+
+	layers, err := docker.ReadImageLayout(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &SizeInfo{FileTypeBreakdown: make(map[string]int64)}
+	top := newTopKFiles(10)
+	dirSizes := make(map[string]int64)
+	// knownFileSizes resolves a hardlink's Linkname to the size already
+	// recorded for it, so the same bytes aren't counted twice.
+	knownFileSizes := make(map[string]int64)
+
+	for _, layer := range layers {
+		if err := streamLayerSizes(o.fs, layer.Path, info, top, dirSizes, knownFileSizes); err != nil {
+			return nil, fmt.Errorf("failed to read layer %s: %v", layer.Digest, err)
+		}
+	}
+
+	info.LargestFiles = top.Sorted()
+	info.LargestDirs = topDirsBySize(dirSizes, 5)
+
+	return info, nil
+}
+
+// streamLayerSizes reads one layer tarball and folds its regular files into
+// info, top, dirSizes, and knownFileSizes.
+func streamLayerSizes(fs afero.Fs, layerPath string, info *SizeInfo, top *topKFiles, dirSizes, knownFileSizes map[string]int64) error {
+	r, closeStream, err := openLayerStream(fs, layerPath)
+	if err != nil {
+		return err
+	}
+	defer closeStream()
+
+	reader := tar.NewReader(r)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("error reading layer tarball: %v", err)
+		}
+
+		name := filepath.Clean(header.Name)
+		base := filepath.Base(name)
+
+		// Whiteout/opaque markers remove content rather than adding it.
+		if base == ".wh..wh..opq" || strings.HasPrefix(base, ".wh.") {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeReg:
+			knownFileSizes[name] = header.Size
+			accumulateFile(info, top, dirSizes, name, header.Size)
+		case tar.TypeLink:
+			// Hardlinks carry no data of their own; record the entry for
+			// visibility (top-K, file type breakdown) using the target's
+			// already-counted size, but don't add it to TotalSize or the
+			// directory rollup a second time.
+			size := knownFileSizes[filepath.Clean(header.Linkname)]
+			top.Add(FileSize{Path: name, Size: size})
+		}
+	}
+
+	return nil
+}
+
+// accumulateFile folds a single regular file's size into the running
+// totals: TotalSize, the top-K largest files, the per-extension breakdown,
+// and the per-directory sizes (rolled up to every parent directory).
+func accumulateFile(info *SizeInfo, top *topKFiles, dirSizes map[string]int64, name string, size int64) {
+	info.TotalSize += size
+	top.Add(FileSize{Path: name, Size: size})
+
+	ext := filepath.Ext(name)
+	if ext == "" {
+		ext = "[no extension]"
+	}
+	info.FileTypeBreakdown[ext] += size
+
+	for dir := filepath.Dir(name); ; dir = filepath.Dir(dir) {
+		dirSizes[dir] += size
+		if dir == "." || dir == string(filepath.Separator) {
+			break
+		}
+	}
+}
+
+// topDirsBySize returns the top count directories by size, sorted
+// descending. count <= 0 returns every directory.
+func topDirsBySize(dirSizes map[string]int64, count int) []DirectoryInfo {
+	dirs := make([]DirectoryInfo, 0, len(dirSizes))
+	for path, size := range dirSizes {
+		dirs = append(dirs, DirectoryInfo{Path: path, Size: size})
+	}
+
+	sort.Slice(dirs, func(i, j int) bool {
+		return dirs[i].Size > dirs[j].Size
+	})
+
+	if count > 0 && count < len(dirs) {
+		return dirs[:count]
+	}
+	return dirs
+}
@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"archive/tar"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAnalyzeSizeStreaming(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "size-streaming-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	layerDir := filepath.Join(tempDir, "layer1")
+	if err := os.MkdirAll(layerDir, 0755); err != nil {
+		t.Fatalf("Failed to create layer directory: %v", err)
+	}
+
+	layerPath := filepath.Join(layerDir, "layer.tar")
+	file, err := os.Create(layerPath)
+	if err != nil {
+		t.Fatalf("Failed to create layer tarball: %v", err)
+	}
+	w := tar.NewWriter(file)
+
+	original := "original file content"
+	if err := w.WriteHeader(&tar.Header{Name: "dir/original.txt", Mode: 0644, Size: int64(len(original)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("Failed to write tar header: %v", err)
+	}
+	if _, err := w.Write([]byte(original)); err != nil {
+		t.Fatalf("Failed to write tar content: %v", err)
+	}
+
+	if err := w.WriteHeader(&tar.Header{Name: "dir/hardlink.txt", Typeflag: tar.TypeLink, Linkname: "dir/original.txt"}); err != nil {
+		t.Fatalf("Failed to write hardlink header: %v", err)
+	}
+
+	if err := w.WriteHeader(&tar.Header{Name: ".wh.ghost.txt", Mode: 0644, Size: 0, Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("Failed to write whiteout header: %v", err)
+	}
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	file.Close()
+
+	info, err := AnalyzeSizeStreaming(tempDir)
+	if err != nil {
+		t.Fatalf("AnalyzeSizeStreaming failed: %v", err)
+	}
+
+	wantTotal := int64(len(original))
+	if info.TotalSize != wantTotal {
+		t.Errorf("TotalSize = %d, expected %d (hardlink should not be double-counted)", info.TotalSize, wantTotal)
+	}
+
+	if size := info.FileTypeBreakdown[".txt"]; size != wantTotal {
+		t.Errorf("FileTypeBreakdown[.txt] = %d, expected %d", size, wantTotal)
+	}
+
+	foundDir := false
+	for _, d := range info.LargestDirs {
+		if d.Path == "dir" {
+			foundDir = true
+			if d.Size != wantTotal {
+				t.Errorf("LargestDirs[dir].Size = %d, expected %d", d.Size, wantTotal)
+			}
+		}
+	}
+	if !foundDir {
+		t.Errorf("LargestDirs missing entry for %q", "dir")
+	}
+
+	foundHardlink := false
+	for _, f := range info.LargestFiles {
+		if f.Path == "dir/hardlink.txt" {
+			foundHardlink = true
+		}
+		if f.Path == "ghost.txt" || f.Path == ".wh.ghost.txt" {
+			t.Errorf("LargestFiles should not include the whiteout entry, got %q", f.Path)
+		}
+	}
+	if !foundHardlink {
+		t.Errorf("LargestFiles missing the hardlink entry")
+	}
+}
@@ -0,0 +1,141 @@
+package analyzer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/kanywst/scallop/internal/docker"
+	"github.com/spf13/afero"
+)
+
+// LayerVerification is the integrity verdict for a single image layer:
+// whether its compressed blob hashes to the digest its manifest lists, and
+// whether its decompressed content hashes to the diffID its image config
+// lists. Either check is left unset (and considered passing) when there is
+// nothing to compare against, e.g. a legacy Docker v1 "<layer-id>/layer.tar"
+// image has no content-addressed digest, and an image whose config couldn't
+// be found has no diffID.
+type LayerVerification struct {
+	LayerIndex int    `json:"layerIndex"`
+	LayerID    string `json:"layerId"`
+
+	ExpectedDigest string `json:"expectedDigest,omitempty"`
+	ActualDigest   string `json:"actualDigest,omitempty"`
+	DigestOK       bool   `json:"digestOk"`
+
+	ExpectedDiffID string `json:"expectedDiffId,omitempty"`
+	ActualDiffID   string `json:"actualDiffId,omitempty"`
+	DiffIDOK       bool   `json:"diffIdOk"`
+}
+
+// Passed reports whether every check VerifyLayers could perform for this
+// layer succeeded.
+func (v LayerVerification) Passed() bool {
+	return v.DigestOK && v.DiffIDOK
+}
+
+// VerifyLayers checks every layer of the image at imagePath against its own
+// manifest and config, the way moby's layer package guards against tampered
+// metadata or a broken tar-split round-trip before serving a layer: the
+// compressed blob is hashed and compared against the digest manifest.json
+// (or index.json) lists for it, and the decompressed stream is hashed and
+// compared against the diffID the image config's rootfs.diff_ids lists.
+// A mismatch on either check means the image was corrupted or modified
+// after it was built, outside of the normal layer history.
+func VerifyLayers(imagePath string, opts ...Option) ([]LayerVerification, error) {
+	o := newOptions(opts...)
+
+	layers, err := docker.ReadImageLayout(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	// Best-effort: skip the diffID check if no image config can be found.
+	cfg, _ := docker.ReadBlobConfig(imagePath)
+
+	results := make([]LayerVerification, len(layers))
+	for i, layer := range layers {
+		result := LayerVerification{LayerIndex: i, LayerID: layer.Digest, DigestOK: true, DiffIDOK: true}
+
+		if algo, expectedHex, ok := splitDigest(layer.Digest); ok {
+			actualHex, err := hashFile(o.fs, layer.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to hash layer %s: %v", layer.Digest, err)
+			}
+			result.ExpectedDigest = layer.Digest
+			result.ActualDigest = algo + ":" + actualHex
+			result.DigestOK = actualHex == expectedHex
+		}
+
+		if cfg != nil && i < len(cfg.RootFS.DiffIDs) {
+			_, diffID, err := layerDiffStats(o.fs, layer.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read layer %s: %v", layer.Digest, err)
+			}
+			result.ExpectedDiffID = cfg.RootFS.DiffIDs[i]
+			result.ActualDiffID = diffID
+			result.DiffIDOK = diffID == result.ExpectedDiffID
+		}
+
+		results[i] = result
+	}
+
+	return results, nil
+}
+
+// verifyLayerDigests runs VerifyLayers against imagePath and returns an
+// error describing every layer that failed, or nil if they all passed.
+func verifyLayerDigests(imagePath string, opts ...Option) error {
+	results, err := VerifyLayers(imagePath, opts...)
+	if err != nil {
+		return err
+	}
+
+	var failures []string
+	for _, r := range results {
+		if r.Passed() {
+			continue
+		}
+		if !r.DigestOK {
+			failures = append(failures, fmt.Sprintf("layer %s: digest mismatch: expected %s, got %s", r.LayerID, r.ExpectedDigest, r.ActualDigest))
+		}
+		if !r.DiffIDOK {
+			failures = append(failures, fmt.Sprintf("layer %s: diffID mismatch: expected %s, got %s", r.LayerID, r.ExpectedDiffID, r.ActualDiffID))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("layer verification failed: %s", strings.Join(failures, "; "))
+	}
+	return nil
+}
+
+// splitDigest splits a "<algorithm>:<hex>" digest such as
+// "sha256:abcd...", reporting ok false for a legacy Docker v1 layer ID,
+// which has no algorithm prefix and therefore nothing to verify a blob
+// digest against.
+func splitDigest(digest string) (algo, hex string, ok bool) {
+	i := strings.Index(digest, ":")
+	if i < 0 {
+		return "", "", false
+	}
+	return digest[:i], digest[i+1:], true
+}
+
+// hashFile returns the lowercase hex-encoded sha256 digest of the file at
+// path's contents.
+func hashFile(fs afero.Fs, path string) (string, error) {
+	f, err := fs.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
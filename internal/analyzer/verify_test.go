@@ -0,0 +1,195 @@
+package analyzer
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+// writeOCILayout builds a minimal OCI Image Layout under dir with a single
+// gzip-compressed layer, returning the layer's digest and diffID.
+func writeOCILayout(t *testing.T, dir string, plain []byte) (layerDigest, diffID string) {
+	t.Helper()
+
+	blobsDir := filepath.Join(dir, "blobs", "sha256")
+	if err := os.MkdirAll(blobsDir, 0755); err != nil {
+		t.Fatalf("Failed to create blobs directory: %v", err)
+	}
+
+	uncompressedHash := sha256.Sum256(plain)
+	diffID = "sha256:" + hex.EncodeToString(uncompressedHash[:])
+
+	var gzBuf bytes.Buffer
+	gz := gzip.NewWriter(&gzBuf)
+	if _, err := gz.Write(plain); err != nil {
+		t.Fatalf("Failed to gzip layer content: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+
+	layerHash := sha256.Sum256(gzBuf.Bytes())
+	layerHex := hex.EncodeToString(layerHash[:])
+	layerDigest = "sha256:" + layerHex
+	if err := os.WriteFile(filepath.Join(blobsDir, layerHex), gzBuf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to write layer blob: %v", err)
+	}
+
+	config := struct {
+		RootFS struct {
+			DiffIDs []string `json:"diff_ids"`
+		} `json:"rootfs"`
+	}{}
+	config.RootFS.DiffIDs = []string{diffID}
+	configData, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Failed to marshal image config: %v", err)
+	}
+	configHash := sha256.Sum256(configData)
+	configHex := hex.EncodeToString(configHash[:])
+	if err := os.WriteFile(filepath.Join(blobsDir, configHex), configData, 0644); err != nil {
+		t.Fatalf("Failed to write image config blob: %v", err)
+	}
+
+	manifest := struct {
+		Config struct {
+			Digest string `json:"digest"`
+		} `json:"config"`
+		Layers []struct {
+			Digest string `json:"digest"`
+		} `json:"layers"`
+	}{}
+	manifest.Config.Digest = "sha256:" + configHex
+	manifest.Layers = []struct {
+		Digest string `json:"digest"`
+	}{{Digest: layerDigest}}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("Failed to marshal image manifest: %v", err)
+	}
+	manifestHash := sha256.Sum256(manifestData)
+	manifestHex := hex.EncodeToString(manifestHash[:])
+	if err := os.WriteFile(filepath.Join(blobsDir, manifestHex), manifestData, 0644); err != nil {
+		t.Fatalf("Failed to write image manifest blob: %v", err)
+	}
+
+	index := struct {
+		Manifests []struct {
+			Digest string `json:"digest"`
+		} `json:"manifests"`
+	}{}
+	index.Manifests = []struct {
+		Digest string `json:"digest"`
+	}{{Digest: "sha256:" + manifestHex}}
+	indexData, err := json.Marshal(index)
+	if err != nil {
+		t.Fatalf("Failed to marshal index.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "index.json"), indexData, 0644); err != nil {
+		t.Fatalf("Failed to write index.json: %v", err)
+	}
+
+	return layerDigest, diffID
+}
+
+func TestVerifyLayersPasses(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "verify-layers-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	layerDigest, diffID := writeOCILayout(t, tempDir, []byte("uncompressed layer content"))
+
+	results, err := VerifyLayers(tempDir)
+	if err != nil {
+		t.Fatalf("VerifyLayers failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("len(results) = %d, expected 1", len(results))
+	}
+
+	r := results[0]
+	if !r.Passed() {
+		t.Errorf("result = %+v, expected Passed() true", r)
+	}
+	if r.ExpectedDigest != layerDigest || r.ActualDigest != layerDigest {
+		t.Errorf("digest = (expected %q, actual %q), expected both %q", r.ExpectedDigest, r.ActualDigest, layerDigest)
+	}
+	if r.ExpectedDiffID != diffID || r.ActualDiffID != diffID {
+		t.Errorf("diffID = (expected %q, actual %q), expected both %q", r.ExpectedDiffID, r.ActualDiffID, diffID)
+	}
+}
+
+func TestVerifyLayersDetectsTamperedBlob(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "verify-layers-tamper-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	layerDigest, _ := writeOCILayout(t, tempDir, []byte("uncompressed layer content"))
+
+	// Tamper with the blob after the manifest/config were written against
+	// its original content.
+	blobPath := filepath.Join(tempDir, "blobs", "sha256", layerDigest[len("sha256:"):])
+	var gzBuf bytes.Buffer
+	gz := gzip.NewWriter(&gzBuf)
+	gz.Write([]byte("maliciously repackaged content"))
+	gz.Close()
+	if err := os.WriteFile(blobPath, gzBuf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to tamper with layer blob: %v", err)
+	}
+
+	results, err := VerifyLayers(tempDir)
+	if err != nil {
+		t.Fatalf("VerifyLayers failed: %v", err)
+	}
+	if len(results) != 1 {
+		t.Fatalf("len(results) = %d, expected 1", len(results))
+	}
+	if results[0].Passed() {
+		t.Errorf("results[0].Passed() = true, expected false after tampering with the blob")
+	}
+	if results[0].DigestOK {
+		t.Errorf("results[0].DigestOK = true, expected false: blob content no longer matches its digest")
+	}
+
+	if err := verifyLayerDigests(tempDir); err == nil {
+		t.Errorf("verifyLayerDigests should fail for a tampered layer")
+	}
+}
+
+func TestAnalyzeSizeWithVerifyDigestsFailsOnTamperedLayer(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "analyze-size-verify-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	layerDigest, _ := writeOCILayout(t, tempDir, []byte("uncompressed layer content"))
+
+	blobPath := filepath.Join(tempDir, "blobs", "sha256", layerDigest[len("sha256:"):])
+	var gzBuf bytes.Buffer
+	gz := gzip.NewWriter(&gzBuf)
+	gz.Write([]byte("maliciously repackaged content"))
+	gz.Close()
+	if err := os.WriteFile(blobPath, gzBuf.Bytes(), 0644); err != nil {
+		t.Fatalf("Failed to tamper with layer blob: %v", err)
+	}
+
+	if _, err := AnalyzeSize(tempDir, WithFS(afero.NewOsFs()), WithVerifyDigests(true)); err == nil {
+		t.Errorf("AnalyzeSize with WithVerifyDigests should fail on a tampered layer")
+	}
+
+	if _, err := AnalyzeSize(tempDir, WithFS(afero.NewOsFs())); err != nil {
+		t.Errorf("AnalyzeSize without WithVerifyDigests should not fail: %v", err)
+	}
+}
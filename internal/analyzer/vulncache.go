@@ -0,0 +1,75 @@
+package analyzer
+
+import (
+	"sync"
+	"time"
+)
+
+// cachedVulnEntry is one package's cached Query result, along with when it
+// was fetched so cachingVulnDB can tell whether it's still within ttl.
+type cachedVulnEntry struct {
+	issues   []SecurityIssue
+	cachedAt time.Time
+}
+
+// cachingVulnDB wraps a VulnerabilityDB with an in-memory, per-process
+// cache of Query results, keyed by ecosystem/name/version and valid for
+// ttl. A caller that scans many layers or images in the same process (e.g.
+// AnalyzeSecurityPerLayer, which queries once per layer and commonly sees
+// the same package version survive unchanged across several of them) skips
+// re-querying db for a version it already looked up recently, at the cost
+// of not noticing a new advisory for that exact version published inside
+// the TTL window.
+//
+// The underlying VulnerabilityDB interface returns a flat []SecurityIssue
+// for a whole batch with no structural link back to which input Package
+// produced which issue, so a cache hit or miss is decided per package
+// rather than per batch: any package not already cached is queried db
+// individually (one Query call per miss) instead of batched together the
+// way a cold, uncached call would be. That trades away some of
+// httpOSVClient's batching efficiency on a fully cold cache for a cache
+// that stays correct regardless of which VulnerabilityDB implementation
+// it wraps.
+type cachingVulnDB struct {
+	db  VulnerabilityDB
+	ttl time.Duration
+
+	mu    sync.Mutex
+	cache map[string]cachedVulnEntry
+}
+
+// newCachingVulnDB returns a VulnerabilityDB that caches db's Query results
+// per package for ttl.
+func newCachingVulnDB(db VulnerabilityDB, ttl time.Duration) VulnerabilityDB {
+	return &cachingVulnDB{db: db, ttl: ttl, cache: make(map[string]cachedVulnEntry)}
+}
+
+func (c *cachingVulnDB) Query(pkgs []Package) ([]SecurityIssue, error) {
+	now := time.Now()
+	var issues []SecurityIssue
+
+	for _, pkg := range pkgs {
+		key := pkg.Ecosystem + "/" + pkg.Name + "@" + pkg.Version
+
+		c.mu.Lock()
+		entry, ok := c.cache[key]
+		c.mu.Unlock()
+		if ok && now.Sub(entry.cachedAt) < c.ttl {
+			issues = append(issues, entry.issues...)
+			continue
+		}
+
+		fresh, err := c.db.Query([]Package{pkg})
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		c.cache[key] = cachedVulnEntry{issues: fresh, cachedAt: now}
+		c.mu.Unlock()
+
+		issues = append(issues, fresh...)
+	}
+
+	return issues, nil
+}
@@ -0,0 +1,93 @@
+package analyzer
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// countingVulnDB is a stub VulnerabilityDB that counts how many packages it
+// was actually asked to look up, so tests can tell whether cachingVulnDB
+// served a repeated lookup from its cache instead of calling through.
+type countingVulnDB struct {
+	queried int
+}
+
+func (c *countingVulnDB) Query(pkgs []Package) ([]SecurityIssue, error) {
+	c.queried += len(pkgs)
+	var issues []SecurityIssue
+	for _, pkg := range pkgs {
+		if pkg.Name == "vulnerable-pkg" {
+			issues = append(issues, SecurityIssue{
+				Type:        "VULNERABLE_PACKAGE",
+				Description: pkg.Name + "@" + pkg.Version,
+				Severity:    "HIGH",
+			})
+		}
+	}
+	return issues, nil
+}
+
+func TestCachingVulnDBServesRepeatedLookupsFromCache(t *testing.T) {
+	stub := &countingVulnDB{}
+	db := newCachingVulnDB(stub, time.Minute)
+
+	pkgs := []Package{{Name: "vulnerable-pkg", Version: "1.0.0", Ecosystem: "npm"}}
+
+	issues, err := db.Query(pkgs)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("len(issues) = %d, expected 1", len(issues))
+	}
+	if stub.queried != 1 {
+		t.Fatalf("stub.queried = %d after first call, expected 1", stub.queried)
+	}
+
+	issues, err = db.Query(pkgs)
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if len(issues) != 1 {
+		t.Fatalf("len(issues) = %d, expected 1", len(issues))
+	}
+	if stub.queried != 1 {
+		t.Errorf("stub.queried = %d after second call, expected 1 (should have been served from cache)", stub.queried)
+	}
+}
+
+func TestCachingVulnDBRequeriesAfterTTLExpires(t *testing.T) {
+	stub := &countingVulnDB{}
+	db := newCachingVulnDB(stub, 0)
+
+	pkgs := []Package{{Name: "vulnerable-pkg", Version: "1.0.0", Ecosystem: "npm"}}
+
+	if _, err := db.Query(pkgs); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if _, err := db.Query(pkgs); err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+	if stub.queried != 2 {
+		t.Errorf("stub.queried = %d with a zero TTL, expected 2 (every call should be a miss)", stub.queried)
+	}
+}
+
+func TestAnalyzeSecurityWithVulnDBUsesGivenBackend(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "analyze-security-vulndb-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	stub := &countingVulnDB{}
+
+	result, err := AnalyzeSecurity(tempDir, WithVulnDB(stub))
+	if err != nil {
+		t.Fatalf("AnalyzeSecurity failed: %v", err)
+	}
+	if result == nil {
+		t.Fatal("AnalyzeSecurity returned a nil result")
+	}
+}
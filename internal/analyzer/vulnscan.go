@@ -0,0 +1,289 @@
+package analyzer
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/kanywst/scallop/internal/sbom"
+)
+
+// Package is a single installed package discovered in an image, identified
+// the way OSV.dev expects: an ecosystem plus name and version.
+type Package struct {
+	Name      string
+	Version   string
+	Ecosystem string
+	Source    string // the file the package was discovered in, relative to the image root
+	BOMRef    string // the sbom.Component this package was adapted from, if any
+}
+
+// OSVClient queries a vulnerability database for the packages it is given
+// and returns the resulting SecurityIssues. The default implementation
+// talks to the public OSV.dev API; an offline implementation can be backed
+// by a pre-downloaded OSV JSON dump for air-gapped CI.
+type OSVClient interface {
+	Query(pkgs []Package) ([]SecurityIssue, error)
+}
+
+// osvQuery/osvBatchRequest/osvBatchResponse/osvVulnerability mirror the
+// subset of the OSV.dev querybatch API (https://osv.dev) that scallop uses.
+type osvQuery struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Version string `json:"version"`
+}
+
+type osvBatchRequest struct {
+	Queries []osvQuery `json:"queries"`
+}
+
+type osvVulnerability struct {
+	ID       string   `json:"id"`
+	Summary  string   `json:"summary"`
+	Aliases  []string `json:"aliases"`
+	Severity []struct {
+		Type  string `json:"type"`
+		Score string `json:"score"`
+	} `json:"severity"`
+	References []struct {
+		URL string `json:"url"`
+	} `json:"references"`
+}
+
+// osvRange is one of an advisory's "affected[].ranges" entries: a sequence
+// of introduced/fixed events describing which versions of a package are
+// affected. See rangeFixedVersion and versionInRange for how these are
+// matched against an installed package's version.
+type osvRange struct {
+	Type   string `json:"type"`
+	Events []struct {
+		Introduced string `json:"introduced"`
+		Fixed      string `json:"fixed"`
+	} `json:"events"`
+}
+
+// osvAffected is one "affected" entry of an OSV record: the package it
+// covers plus the version ranges known to be vulnerable.
+type osvAffected struct {
+	Package struct {
+		Name      string `json:"name"`
+		Ecosystem string `json:"ecosystem"`
+	} `json:"package"`
+	Ranges []osvRange `json:"ranges"`
+}
+
+type osvBatchResponse struct {
+	Results []struct {
+		Vulns []osvVulnerability `json:"vulns"`
+	} `json:"results"`
+}
+
+// httpOSVClient queries the public OSV.dev API over HTTP.
+type httpOSVClient struct {
+	endpoint string
+	client   *http.Client
+}
+
+// NewOSVClient returns an OSVClient backed by the public OSV.dev API.
+func NewOSVClient() OSVClient {
+	return &httpOSVClient{
+		endpoint: "https://api.osv.dev/v1/querybatch",
+		client:   &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+func (c *httpOSVClient) Query(pkgs []Package) ([]SecurityIssue, error) {
+	if len(pkgs) == 0 {
+		return nil, nil
+	}
+
+	req := osvBatchRequest{Queries: make([]osvQuery, len(pkgs))}
+	for i, pkg := range pkgs {
+		req.Queries[i].Package.Name = pkg.Name
+		req.Queries[i].Package.Ecosystem = pkg.Ecosystem
+		req.Queries[i].Version = pkg.Version
+	}
+
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal OSV query: %v", err)
+	}
+
+	resp, err := c.client.Post(c.endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query OSV.dev: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var batch osvBatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&batch); err != nil {
+		return nil, fmt.Errorf("failed to decode OSV response: %v", err)
+	}
+
+	return vulnerabilitiesToIssues(pkgs, batch), nil
+}
+
+// offlineAdvisory pairs one OSV record with the ranges of the single
+// affected package that indexed it, so Query can check an installed
+// version against them without re-scanning every "affected" entry.
+type offlineAdvisory struct {
+	vuln   osvVulnerability
+	ranges []osvRange
+}
+
+// offlineOSVClient matches packages against a pre-downloaded OSV JSON dump,
+// so CI pipelines without network access can still detect vulnerabilities.
+// The dump is expected to be a JSON array of OSV records (id, summary,
+// affected, ...) in the same shape osv.dev publishes.
+type offlineOSVClient struct {
+	advisories map[string][]offlineAdvisory // keyed by "ecosystem/name"
+}
+
+// NewOfflineOSVClient loads an OSV JSON dump from path for offline matching.
+// FetchOSVBundle produces dumps in this format; osv.dev's own per-ecosystem
+// zip exports (one such record per file) also unpack into it.
+func NewOfflineOSVClient(path string) (OSVClient, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read OSV dump: %v", err)
+	}
+	return newOfflineOSVClientFromJSON(data)
+}
+
+func newOfflineOSVClientFromJSON(data []byte) (OSVClient, error) {
+	var records []struct {
+		osvVulnerability
+		Affected []osvAffected `json:"affected"`
+	}
+	if err := json.Unmarshal(data, &records); err != nil {
+		return nil, fmt.Errorf("failed to parse OSV dump: %v", err)
+	}
+
+	client := &offlineOSVClient{advisories: make(map[string][]offlineAdvisory)}
+	for _, record := range records {
+		for _, affected := range record.Affected {
+			key := affected.Package.Ecosystem + "/" + affected.Package.Name
+			client.advisories[key] = append(client.advisories[key], offlineAdvisory{
+				vuln:   record.osvVulnerability,
+				ranges: affected.Ranges,
+			})
+		}
+	}
+	return client, nil
+}
+
+func (c *offlineOSVClient) Query(pkgs []Package) ([]SecurityIssue, error) {
+	var issues []SecurityIssue
+	for _, pkg := range pkgs {
+		key := pkg.Ecosystem + "/" + pkg.Name
+		for _, advisory := range c.advisories[key] {
+			// A record with no ranges at all (as in a hand-written dump
+			// that only names the affected package) is treated as always
+			// matching, rather than excluding every version.
+			if len(advisory.ranges) > 0 && !versionInRanges(pkg.Version, advisory.ranges) {
+				continue
+			}
+			issues = append(issues, osvIssue(pkg, advisory.vuln, rangesFixedVersion(advisory.ranges)))
+		}
+	}
+	return issues, nil
+}
+
+// vulnerabilitiesToIssues pairs each OSV batch result with the package that
+// produced it, preserving request order as documented by the querybatch API.
+func vulnerabilitiesToIssues(pkgs []Package, batch osvBatchResponse) []SecurityIssue {
+	var issues []SecurityIssue
+	for i, result := range batch.Results {
+		if i >= len(pkgs) {
+			break
+		}
+		for _, vuln := range result.Vulns {
+			// The querybatch endpoint returns bare vuln IDs without
+			// "affected" ranges, so there is no fixed version to report.
+			issues = append(issues, osvIssue(pkgs[i], vuln, ""))
+		}
+	}
+	return issues
+}
+
+// cveAlias returns the first CVE-prefixed identifier for vuln, preferring
+// its own ID if it already is one (GHSA and PYSEC advisories commonly list
+// the CVE as an alias instead).
+func cveAlias(vuln osvVulnerability) string {
+	if strings.HasPrefix(vuln.ID, "CVE-") {
+		return vuln.ID
+	}
+	for _, alias := range vuln.Aliases {
+		if strings.HasPrefix(alias, "CVE-") {
+			return alias
+		}
+	}
+	return ""
+}
+
+func osvIssue(pkg Package, vuln osvVulnerability, fixedVersion string) SecurityIssue {
+	severity := "MEDIUM"
+	var cvssScore string
+	for _, s := range vuln.Severity {
+		if s.Type == "CVSS_V3" || s.Type == "CVSS_V2" {
+			severity = "HIGH"
+			cvssScore = s.Score
+		}
+	}
+
+	var references []string
+	for _, ref := range vuln.References {
+		references = append(references, ref.URL)
+	}
+
+	return SecurityIssue{
+		Type:         "VULNERABLE_PACKAGE",
+		CVEID:        cveAlias(vuln),
+		CVSSScore:    cvssScore,
+		FixedVersion: fixedVersion,
+		References:   references,
+		Path:         pkg.Source,
+		Description:  fmt.Sprintf("%s@%s: %s (%s)", pkg.Name, pkg.Version, vuln.Summary, vuln.ID),
+		Severity:     severity,
+		BOMRef:       pkg.BOMRef,
+	}
+}
+
+// EnumeratePackages walks imagePath for common OS package databases,
+// language manifests/lockfiles, and Go binaries' embedded module info, and
+// returns every installed package it can identify as a []Package, the
+// shape OSVClient.Query expects. The discovery walk itself lives in
+// internal/sbom, which additionally renders the same inventory as a real
+// CycloneDX/SPDX Software Bill of Materials (see sbom.Generate); this just
+// adapts its richer sbom.Component into the narrower Package OSV querying
+// needs.
+func EnumeratePackages(imagePath string) ([]Package, error) {
+	doc, err := sbom.Generate(imagePath)
+	if err != nil {
+		return nil, err
+	}
+
+	pkgs := make([]Package, len(doc.Components))
+	for i, c := range doc.Components {
+		pkgs[i] = Package{Name: c.Name, Version: c.Version, Ecosystem: c.Ecosystem, Source: c.Source, BOMRef: c.BOMRef}
+	}
+	return pkgs, nil
+}
+
+// ScanVulnerabilities enumerates installed packages in imagePath and queries
+// client for known vulnerabilities, producing VULNERABLE_PACKAGE issues with
+// real advisory IDs.
+func ScanVulnerabilities(imagePath string, client OSVClient) ([]SecurityIssue, error) {
+	pkgs, err := EnumeratePackages(imagePath)
+	if err != nil {
+		return nil, err
+	}
+	return client.Query(pkgs)
+}
@@ -0,0 +1,99 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestEnumeratePackages(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "vulnscan-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dpkgDir := filepath.Join(tempDir, "var", "lib", "dpkg")
+	if err := os.MkdirAll(dpkgDir, 0755); err != nil {
+		t.Fatalf("Failed to create dpkg directory: %v", err)
+	}
+	dpkgStatus := "Package: openssl\nStatus: install ok installed\nVersion: 1.1.1n-0+deb11u1\n\nPackage: curl\nVersion: 7.74.0-1.3+deb11u7\n"
+	if err := os.WriteFile(filepath.Join(dpkgDir, "status"), []byte(dpkgStatus), 0644); err != nil {
+		t.Fatalf("Failed to write dpkg status: %v", err)
+	}
+
+	apkDir := filepath.Join(tempDir, "lib", "apk", "db")
+	if err := os.MkdirAll(apkDir, 0755); err != nil {
+		t.Fatalf("Failed to create apk directory: %v", err)
+	}
+	apkInstalled := "P:busybox\nV:1.35.0-r17\n\nP:musl\nV:1.2.3-r0\n"
+	if err := os.WriteFile(filepath.Join(apkDir, "installed"), []byte(apkInstalled), 0644); err != nil {
+		t.Fatalf("Failed to write apk db: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tempDir, "requirements.txt"), []byte("django==3.2.0\n# comment\nflask==2.0.0\n"), 0644); err != nil {
+		t.Fatalf("Failed to write requirements.txt: %v", err)
+	}
+
+	pkgs, err := EnumeratePackages(tempDir)
+	if err != nil {
+		t.Fatalf("EnumeratePackages failed: %v", err)
+	}
+
+	want := map[string]string{
+		"openssl": "1.1.1n-0+deb11u1",
+		"curl":    "7.74.0-1.3+deb11u7",
+		"busybox": "1.35.0-r17",
+		"musl":    "1.2.3-r0",
+		"django":  "3.2.0",
+		"flask":   "2.0.0",
+	}
+
+	got := make(map[string]string)
+	for _, pkg := range pkgs {
+		got[pkg.Name] = pkg.Version
+	}
+
+	for name, version := range want {
+		if got[name] != version {
+			t.Errorf("package %q version = %q, expected %q", name, got[name], version)
+		}
+	}
+}
+
+func TestOfflineOSVClient(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "osv-dump-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	dump := `[
+		{
+			"id": "GHSA-xxxx-yyyy-zzzz",
+			"summary": "Prototype Pollution in lodash",
+			"affected": [{"package": {"name": "lodash", "ecosystem": "npm"}}]
+		}
+	]`
+	dumpPath := filepath.Join(tempDir, "osv-dump.json")
+	if err := os.WriteFile(dumpPath, []byte(dump), 0644); err != nil {
+		t.Fatalf("Failed to write OSV dump: %v", err)
+	}
+
+	client, err := NewOfflineOSVClient(dumpPath)
+	if err != nil {
+		t.Fatalf("NewOfflineOSVClient failed: %v", err)
+	}
+
+	issues, err := client.Query([]Package{{Name: "lodash", Version: "4.17.20", Ecosystem: "npm", Source: "package-lock.json"}})
+	if err != nil {
+		t.Fatalf("Query failed: %v", err)
+	}
+
+	if len(issues) != 1 {
+		t.Fatalf("len(issues) = %d, expected 1", len(issues))
+	}
+	if issues[0].Type != "VULNERABLE_PACKAGE" {
+		t.Errorf("issues[0].Type = %q, expected VULNERABLE_PACKAGE", issues[0].Type)
+	}
+}
@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/afero"
+)
+
+// sizeWalkResult holds every metric a single walk of an image's extracted
+// tree can produce: AnalyzeSize, GetTopDirectories, getLargestFiles, and
+// getFileTypeBreakdown used to each run their own filepath.Walk (the last
+// of those, via GetTopDirectories's per-directory getDirSize, re-walking
+// the whole subtree once per directory it visited). walkSize computes all
+// of them together in one pass, the same way AnalyzeSizeStreaming already
+// does for layer tar streams.
+type sizeWalkResult struct {
+	// totalSize is the sum of every regular file's size.
+	totalSize int64
+	// largestFiles holds the largest files seen, in descending order,
+	// bounded by the topFilesCount a caller passed to walkSize.
+	largestFiles []FileSize
+	// fileTypeBreakdown sums file sizes by extension.
+	fileTypeBreakdown map[string]int64
+	// dirSizes maps every directory's path, relative to the walk root
+	// ("." for the root itself), to the cumulative size of every regular
+	// file beneath it. A file's size is added to every ancestor directory
+	// as the file is visited, rather than re-walking each directory's
+	// subtree separately.
+	dirSizes map[string]int64
+}
+
+// walkSize walks fs from root once, computing totalSize, fileTypeBreakdown,
+// dirSizes for every directory in the tree, and (when trackFiles is true)
+// the topFilesCount largest files via the same bounded topKFiles heap
+// AnalyzeSizeStreaming uses. topFilesCount <= 0 keeps every file seen,
+// matching getLargestFiles' historical "count <= 0 means all files"
+// behavior.
+func walkSize(fs afero.Fs, root string, topFilesCount int, trackFiles bool) (*sizeWalkResult, error) {
+	result := &sizeWalkResult{
+		fileTypeBreakdown: make(map[string]int64),
+		dirSizes:          map[string]int64{".": 0},
+	}
+
+	var top *topKFiles
+	if trackFiles {
+		top = newTopKFiles(topFilesCount)
+	}
+
+	err := afero.Walk(fs, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			if relPath == "." {
+				return nil
+			}
+			if _, ok := result.dirSizes[relPath]; !ok {
+				result.dirSizes[relPath] = 0
+			}
+			return nil
+		}
+
+		size := info.Size()
+		result.totalSize += size
+
+		ext := filepath.Ext(path)
+		if ext == "" {
+			ext = "[no extension]"
+		}
+		result.fileTypeBreakdown[ext] += size
+
+		for dir := filepath.Dir(relPath); ; dir = filepath.Dir(dir) {
+			result.dirSizes[dir] += size
+			if dir == "." {
+				break
+			}
+		}
+
+		if trackFiles {
+			top.Add(FileSize{Path: relPath, Size: size})
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if trackFiles {
+		result.largestFiles = top.Sorted()
+	}
+
+	return result, nil
+}
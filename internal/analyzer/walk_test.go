@@ -0,0 +1,104 @@
+package analyzer
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/afero"
+)
+
+func TestWalkSizePropagatesDirSizesUpTheTree(t *testing.T) {
+	fs := afero.NewMemMapFs()
+	files := map[string]string{
+		"a.txt":              "12345",
+		"dir1/b.txt":         "1234567890",
+		"dir1/sub/c.txt":     "123",
+		"dir2/d.txt":         "12",
+		"dir2/sub/sub2/e.go": "1",
+	}
+	for path, content := range files {
+		if err := afero.WriteFile(fs, filepath.Join("/image", path), []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %q: %v", path, err)
+		}
+	}
+
+	result, err := walkSize(fs, "/image", 2, true)
+	if err != nil {
+		t.Fatalf("walkSize failed: %v", err)
+	}
+
+	wantTotal := int64(5 + 10 + 3 + 2 + 1)
+	if result.totalSize != wantTotal {
+		t.Errorf("totalSize = %d, expected %d", result.totalSize, wantTotal)
+	}
+
+	wantDirSizes := map[string]int64{
+		".":             wantTotal,
+		"dir1":          13,
+		"dir1/sub":      3,
+		"dir2":          3,
+		"dir2/sub":      1,
+		"dir2/sub/sub2": 1,
+	}
+	for dir, want := range wantDirSizes {
+		if got := result.dirSizes[dir]; got != want {
+			t.Errorf("dirSizes[%q] = %d, expected %d", dir, got, want)
+		}
+	}
+
+	if len(result.largestFiles) != 2 {
+		t.Fatalf("len(largestFiles) = %d, expected 2", len(result.largestFiles))
+	}
+	if result.largestFiles[0].Path != "dir1/b.txt" || result.largestFiles[0].Size != 10 {
+		t.Errorf("largestFiles[0] = %+v, expected dir1/b.txt with size 10", result.largestFiles[0])
+	}
+	if result.largestFiles[1].Path != "a.txt" || result.largestFiles[1].Size != 5 {
+		t.Errorf("largestFiles[1] = %+v, expected a.txt with size 5", result.largestFiles[1])
+	}
+
+	if result.fileTypeBreakdown[".txt"] != 5+10+3+2 {
+		t.Errorf("fileTypeBreakdown[.txt] = %d, expected %d", result.fileTypeBreakdown[".txt"], 5+10+3+2)
+	}
+	if result.fileTypeBreakdown[".go"] != 1 {
+		t.Errorf("fileTypeBreakdown[.go] = %d, expected 1", result.fileTypeBreakdown[".go"])
+	}
+}
+
+// BenchmarkWalkSize exercises walkSize against a synthesized ~100k-file
+// tree, to catch a regression back to AnalyzeSize's old four-independent-
+// walks approach (the last of which, via GetTopDirectories' per-directory
+// getDirSize, re-walked every subtree once per directory it contained).
+func BenchmarkWalkSize(b *testing.B) {
+	const (
+		levels       = 3
+		dirsPerLevel = 10
+		filesPerDir  = 100
+	)
+
+	fs := afero.NewMemMapFs()
+
+	var buildDir func(path string, depth int)
+	buildDir = func(path string, depth int) {
+		for i := 0; i < filesPerDir; i++ {
+			fp := filepath.Join(path, fmt.Sprintf("file%d.txt", i))
+			if err := afero.WriteFile(fs, fp, []byte("benchmark file content"), 0644); err != nil {
+				b.Fatalf("Failed to write %q: %v", fp, err)
+			}
+		}
+		if depth == 0 {
+			return
+		}
+		for i := 0; i < dirsPerLevel; i++ {
+			buildDir(filepath.Join(path, fmt.Sprintf("dir%d", i)), depth-1)
+		}
+	}
+	buildDir("/image", levels)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := walkSize(fs, "/image", 10, true); err != nil {
+			b.Fatalf("walkSize failed: %v", err)
+		}
+	}
+}
@@ -2,7 +2,6 @@ package docker
 
 import (
 	"archive/tar"
-	"compress/gzip"
 	"fmt"
 	"io"
 	"os"
@@ -18,17 +17,43 @@ func IsDockerImageName(name string) bool {
 	return !strings.Contains(name, "/") || strings.Contains(name, ":")
 }
 
-// ExtractImage extracts a Docker image to the specified directory
-// It handles both local tar files and Docker image names from Docker daemon
+// ExtractImage extracts a Docker image to the specified directory, with no
+// limit on extracted size or file count. Use ExtractImageWithOptions to
+// bound those for an image of unknown or untrusted origin.
+// It handles local tar files, a directory containing an already-unpacked
+// image layout, and Docker image names pulled from the Docker daemon.
 func ExtractImage(imagePath string, destDir string) (string, error) {
+	return ExtractImageWithOptions(imagePath, destDir, utils.ExtractOptions{})
+}
+
+// ExtractImageWithOptions extracts a Docker image the same way ExtractImage
+// does, but applies opts' MaxTotalSize/MaxFileCount to every tar it
+// extracts: the image save tarball itself and, for the legacy Docker v1
+// layout, each layer.tar it contains. config.SecurityConfig is the natural
+// place a caller surfaces these limits from.
+func ExtractImageWithOptions(imagePath string, destDir string, opts utils.ExtractOptions) (string, error) {
 	if IsDockerImageName(imagePath) {
-		return extractFromDockerDaemon(imagePath, destDir)
+		return extractFromDockerDaemon(imagePath, destDir, opts)
+	}
+	if info, err := os.Stat(imagePath); err == nil && info.IsDir() {
+		return extractFromImageDir(imagePath, opts)
 	}
-	return extractFromTarFile(imagePath, destDir)
+	return extractFromTarFile(imagePath, destDir, opts)
+}
+
+// extractFromImageDir treats imagePath as an already-unpacked image layout
+// (legacy `docker save` or OCI Image Layout) rather than a tar file: there is
+// no outer tarball to extract, so it only runs extractLayers over imagePath
+// directly and returns it unchanged as the image directory.
+func extractFromImageDir(imagePath string, opts utils.ExtractOptions) (string, error) {
+	if err := extractLayers(imagePath, opts); err != nil {
+		return "", fmt.Errorf("failed to extract layers: %v", err)
+	}
+	return imagePath, nil
 }
 
 // extractFromDockerDaemon saves a Docker image from the Docker daemon and extracts it
-func extractFromDockerDaemon(imageName string, destDir string) (string, error) {
+func extractFromDockerDaemon(imageName string, destDir string, opts utils.ExtractOptions) (string, error) {
 	// Create a temporary file to save the Docker image
 	tempFile, err := os.CreateTemp("", "docker-image-*.tar")
 	if err != nil {
@@ -40,15 +65,20 @@ func extractFromDockerDaemon(imageName string, destDir string) (string, error) {
 	// Save the Docker image to a tar file
 	saveCmd := exec.Command("docker", "save", "-o", tempFile.Name(), imageName)
 	if err := saveCmd.Run(); err != nil {
+		// No reachable Docker daemon (or the image isn't pulled locally):
+		// fall back to pulling directly from the registry.
+		if imageDir, pullErr := PullImage(imageName, destDir, PullOptions{}); pullErr == nil {
+			return imageDir, nil
+		}
 		return "", fmt.Errorf("failed to save Docker image: %v", err)
 	}
 
 	// Extract the saved image
-	return extractFromTarFile(tempFile.Name(), destDir)
+	return extractFromTarFile(tempFile.Name(), destDir, opts)
 }
 
 // extractFromTarFile extracts a Docker image from a tar file
-func extractFromTarFile(tarPath string, destDir string) (string, error) {
+func extractFromTarFile(tarPath string, destDir string, opts utils.ExtractOptions) (string, error) {
 	// Open the tar file
 	file, err := os.Open(tarPath)
 	if err != nil {
@@ -62,108 +92,94 @@ func extractFromTarFile(tarPath string, destDir string) (string, error) {
 		return "", fmt.Errorf("failed to create image directory: %v", err)
 	}
 
-	// Check if it's a gzipped tar file
-	var tarReader *tar.Reader
-	if strings.HasSuffix(tarPath, ".gz") || strings.HasSuffix(tarPath, ".tgz") {
-		gzReader, err := gzip.NewReader(file)
-		if err != nil {
-			return "", fmt.Errorf("failed to create gzip reader: %v", err)
-		}
-		defer gzReader.Close()
-		tarReader = tar.NewReader(gzReader)
-	} else {
-		tarReader = tar.NewReader(file)
+	// utils.DecompressStream auto-detects gzip/zstd/xz/bzip2 from the
+	// stream's magic bytes, rather than this package guessing from tarPath's
+	// suffix (which missed e.g. a zstd-compressed save tarball entirely).
+	stream, err := utils.DecompressStream(file)
+	if err != nil {
+		return "", fmt.Errorf("failed to open tar file: %v", err)
 	}
-
-	// Extract the tar file
-	for {
-		header, err := tarReader.Next()
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return "", fmt.Errorf("error reading tar file: %v", err)
-		}
-
-		// Skip if the header is nil
-		if header == nil {
-			continue
-		}
-
-		// Create the file path
-		target := filepath.Join(imageDir, header.Name)
-
-		// Check for path traversal attacks
-		if !strings.HasPrefix(target, imageDir) {
-			return "", fmt.Errorf("invalid tar file: contains path traversal attack")
-		}
-
-		// Handle different types of files
-		switch header.Typeflag {
-		case tar.TypeDir:
-			// Create directory
-			if err := os.MkdirAll(target, 0755); err != nil {
-				return "", fmt.Errorf("failed to create directory: %v", err)
-			}
-		case tar.TypeReg:
-			// Create directory for the file if it doesn't exist
-			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
-				return "", fmt.Errorf("failed to create directory: %v", err)
-			}
-
-			// Create the file
-			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, os.FileMode(header.Mode))
-			if err != nil {
-				return "", fmt.Errorf("failed to create file: %v", err)
-			}
-
-			// Copy the file content
-			if _, err := io.Copy(file, tarReader); err != nil {
-				file.Close()
-				return "", fmt.Errorf("failed to copy file content: %v", err)
-			}
-			file.Close()
-		case tar.TypeSymlink:
-			// Create symlink
-			if err := os.Symlink(header.Linkname, target); err != nil {
-				return "", fmt.Errorf("failed to create symlink: %v", err)
-			}
-		default:
-			// Skip other types of files
-		}
+	defer stream.Close()
+
+	// Extract the tar file. utils.ExtractTarReader applies the hardening a
+	// Docker image save tarball, of all the tar sources this package deals
+	// with, most needs: it's the one most likely to have been produced by
+	// (or tampered with via) an untrusted `docker save`/registry pull.
+	if err := utils.ExtractTarReader(tar.NewReader(stream), imageDir, opts); err != nil {
+		return "", fmt.Errorf("failed to extract tar file: %v", err)
 	}
 
 	// Extract layer tarballs if they exist
-	if err := extractLayers(imageDir); err != nil {
+	if err := extractLayers(imageDir, opts); err != nil {
 		return "", fmt.Errorf("failed to extract layers: %v", err)
 	}
 
 	return imageDir, nil
 }
 
-// extractLayers extracts the layer tarballs in a Docker image
-func extractLayers(imageDir string) error {
-	// Find all layer tarballs
-	layerDirs, err := filepath.Glob(filepath.Join(imageDir, "*/layer.tar"))
+// extractLayers extracts the layer tarballs in a Docker image. It detects
+// the OCI Image Layout / Docker v1.2 layout (index.json or manifest.json
+// referencing blobs/sha256/<digest>) via ReadImageLayout, falling back to
+// the legacy Docker v1 "<layer-id>/layer.tar" tree when neither is present.
+func extractLayers(imageDir string, opts utils.ExtractOptions) error {
+	layers, err := ReadImageLayout(imageDir)
 	if err != nil {
-		return fmt.Errorf("failed to find layer tarballs: %v", err)
+		// Nothing resembling an image layout was found; there are no layer
+		// tarballs to extract.
+		return nil
 	}
 
-	// Extract each layer tarball
-	for _, layerPath := range layerDirs {
-		layerDir := filepath.Dir(layerPath)
-		extractedDir := filepath.Join(layerDir, "extracted")
+	for _, layer := range layers {
+		if err := validateLayerBlob(layer.Path); err != nil {
+			return err
+		}
+
+		extractedDir := extractedDirFor(layer)
 
 		// Create the extracted directory
 		if err := os.MkdirAll(extractedDir, 0755); err != nil {
 			return fmt.Errorf("failed to create extracted directory: %v", err)
 		}
 
-		// Extract the layer tarball
-		if err := utils.ExtractTar(layerPath, extractedDir); err != nil {
+		// Extract the layer tarball. utils.ExtractTarWithOptions decompresses
+		// it first via utils.DecompressStream, so a gzip/zstd/xz/bzip2-
+		// compressed layer.tar.gz/.zst extracts correctly.
+		if err := utils.ExtractTarWithOptions(layer.Path, extractedDir, opts); err != nil {
 			return fmt.Errorf("failed to extract layer tarball: %v", err)
 		}
 	}
 
 	return nil
 }
+
+// validateLayerBlob rejects a layer blob that isn't an archive at all
+// before extractLayers spends time on it, via utils.IsArchive.
+func validateLayerBlob(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open layer blob: %v", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, 262)
+	n, err := io.ReadFull(f, header)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return fmt.Errorf("failed to read layer blob: %v", err)
+	}
+	if !utils.IsArchive(header[:n]) {
+		return fmt.Errorf("layer blob %s does not look like a tar archive", path)
+	}
+	return nil
+}
+
+// extractedDirFor returns the directory a layer's contents should be
+// extracted into: a sibling "extracted" directory for the legacy
+// "<layer-id>/layer.tar" layout (preserved for backwards compatibility), or
+// a "<blob>.extracted" directory next to the blob itself for content-
+// addressed layouts, which have no per-layer directory of their own.
+func extractedDirFor(layer Layer) string {
+	if filepath.Base(layer.Path) == "layer.tar" {
+		return filepath.Join(filepath.Dir(layer.Path), "extracted")
+	}
+	return layer.Path + ".extracted"
+}
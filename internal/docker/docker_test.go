@@ -1,9 +1,12 @@
 package docker
 
 import (
+	"archive/tar"
 	"os"
 	"path/filepath"
 	"testing"
+
+	"github.com/kanywst/scallop/internal/utils"
 )
 
 func TestIsDockerImageName(t *testing.T) {
@@ -88,7 +91,7 @@ func TestExtractFromTarFile(t *testing.T) {
 	}
 
 	// Test with a non-existent tar file
-	_, err = extractFromTarFile("non-existent-file.tar", destDir)
+	_, err = extractFromTarFile("non-existent-file.tar", destDir, utils.ExtractOptions{})
 	if err == nil {
 		t.Errorf("extractFromTarFile with non-existent file should fail")
 	}
@@ -96,3 +99,86 @@ func TestExtractFromTarFile(t *testing.T) {
 	// Note: We can't fully test extractFromTarFile without a real Docker image
 	// or mocking the tar extraction, so we'll skip the actual extraction test
 }
+
+func TestExtractImageAcceptsAlreadyUnpackedDirectory(t *testing.T) {
+	tempDir := t.TempDir()
+
+	// An already-unpacked legacy docker save layout: manifest.json + a
+	// single layer.tar, exactly what ExtractImage would have produced
+	// itself had it been given the outer tarball instead.
+	layerDir := filepath.Join(tempDir, "layer1")
+	if err := os.MkdirAll(layerDir, 0755); err != nil {
+		t.Fatalf("Failed to create layer directory: %v", err)
+	}
+	layerTarPath := filepath.Join(layerDir, "layer.tar")
+	f, err := os.Create(layerTarPath)
+	if err != nil {
+		t.Fatalf("Failed to create layer tarball: %v", err)
+	}
+	tw := tar.NewWriter(f)
+	content := "hello"
+	if err := tw.WriteHeader(&tar.Header{Name: "hello.txt", Typeflag: tar.TypeReg, Size: int64(len(content)), Mode: 0644}); err != nil {
+		t.Fatalf("Failed to write tar header: %v", err)
+	}
+	if _, err := tw.Write([]byte(content)); err != nil {
+		t.Fatalf("Failed to write tar content: %v", err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	f.Close()
+
+	manifest := `[{"Config":"config.json","Layers":["layer1/layer.tar"]}]`
+	if err := os.WriteFile(filepath.Join(tempDir, "manifest.json"), []byte(manifest), 0644); err != nil {
+		t.Fatalf("Failed to write manifest.json: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "config.json"), []byte(`{}`), 0644); err != nil {
+		t.Fatalf("Failed to write config.json: %v", err)
+	}
+
+	imageDir, err := ExtractImage(tempDir, t.TempDir())
+	if err != nil {
+		t.Fatalf("ExtractImage on a directory failed: %v", err)
+	}
+	if imageDir != tempDir {
+		t.Errorf("imageDir = %q, expected ExtractImage to return the directory unchanged (%q)", imageDir, tempDir)
+	}
+
+	extractedPath := filepath.Join(layerDir, "extracted", "hello.txt")
+	got, err := os.ReadFile(extractedPath)
+	if err != nil {
+		t.Fatalf("expected the layer to have been extracted to %s: %v", extractedPath, err)
+	}
+	if string(got) != content {
+		t.Errorf("extracted content = %q, expected %q", got, content)
+	}
+}
+
+func TestExtractImageWithOptionsEnforcesMaxFileCount(t *testing.T) {
+	tempDir := t.TempDir()
+
+	imagePath := filepath.Join(tempDir, "mock-image.tar")
+	f, err := os.Create(imagePath)
+	if err != nil {
+		t.Fatalf("Failed to create mock image file: %v", err)
+	}
+	tw := tar.NewWriter(f)
+	for _, name := range []string{"a.txt", "b.txt", "c.txt"} {
+		if err := tw.WriteHeader(&tar.Header{Name: name, Typeflag: tar.TypeReg, Size: 0, Mode: 0644}); err != nil {
+			t.Fatalf("Failed to write tar header: %v", err)
+		}
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	f.Close()
+
+	destDir := filepath.Join(tempDir, "dest")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	if _, err := ExtractImageWithOptions(imagePath, destDir, utils.ExtractOptions{MaxFileCount: 2}); err == nil {
+		t.Errorf("ExtractImageWithOptions should fail when the tar exceeds MaxFileCount")
+	}
+}
@@ -0,0 +1,61 @@
+package docker
+
+// Image is a fully loaded, already-extracted Docker/OCI image: its layers
+// in manifest order, each paired with the Dockerfile instruction that
+// produced it and the directory its contents were extracted into by
+// ExtractImage.
+type Image struct {
+	// Dir is the extracted image directory LoadImage was given.
+	Dir string
+	// Layers are the image's layers in manifest order.
+	Layers []Layer
+}
+
+// LoadImage builds a structured Image from an image directory already
+// produced by ExtractImage: it reads the layer list the same way
+// ExtractImage's own extraction step does (ReadImageLayout), fills in each
+// Layer's ExtractedDir using the same convention extractLayers used to
+// extract it, and attributes each layer to its Dockerfile instruction from
+// the image config's History.
+//
+// LoadImage does not change ExtractImage's own (string, error) signature,
+// since callers and tests rely on ExtractImage returning just the extracted
+// directory; it is a separate, additive entry point for callers that want
+// the richer Image value, such as per-layer analysis.
+func LoadImage(imageDir string) (*Image, error) {
+	layers, err := ReadImageLayout(imageDir)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range layers {
+		layers[i].ExtractedDir = extractedDirFor(layers[i])
+	}
+
+	if cfg, err := ReadBlobConfig(imageDir); err == nil {
+		commands := nonEmptyLayerCommands(cfg)
+		for i := range layers {
+			if i < len(commands) {
+				layers[i].History = commands[i]
+			}
+		}
+	}
+
+	return &Image{Dir: imageDir, Layers: layers}, nil
+}
+
+// nonEmptyLayerCommands returns cfg.History's CreatedBy strings, in order,
+// skipping entries marked EmptyLayer, so the result lines up positionally
+// with the layers in a manifest: image configs record one history entry per
+// Dockerfile instruction, but only non-empty-layer instructions correspond
+// to an actual layer.
+func nonEmptyLayerCommands(cfg *ImageConfig) []string {
+	commands := make([]string, 0, len(cfg.History))
+	for _, h := range cfg.History {
+		if h.EmptyLayer {
+			continue
+		}
+		commands = append(commands, h.CreatedBy)
+	}
+	return commands
+}
@@ -0,0 +1,88 @@
+package docker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadImage(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "load-image-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	layer1Digest := writeBlob(t, tempDir, "1111", []byte("layer one content"))
+	layer2Digest := writeBlob(t, tempDir, "2222", []byte("layer two content"))
+
+	var config ImageConfig
+	config.History = []struct {
+		CreatedBy  string `json:"created_by"`
+		EmptyLayer bool   `json:"empty_layer"`
+	}{
+		{CreatedBy: "FROM scratch", EmptyLayer: true},
+		{CreatedBy: "COPY one /one", EmptyLayer: false},
+		{CreatedBy: "COPY two /two", EmptyLayer: false},
+	}
+	configData, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("Failed to marshal config: %v", err)
+	}
+	configDigest := writeBlob(t, tempDir, "config", configData)
+
+	manifest := ociManifest{
+		Config: struct {
+			Digest string `json:"digest"`
+		}{Digest: configDigest},
+		Layers: []struct {
+			Digest string `json:"digest"`
+		}{
+			{Digest: layer1Digest},
+			{Digest: layer2Digest},
+		},
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("Failed to marshal manifest: %v", err)
+	}
+	manifestDigest := writeBlob(t, tempDir, "manifest", manifestData)
+
+	index := ociIndex{
+		Manifests: []struct {
+			Digest string `json:"digest"`
+		}{{Digest: manifestDigest}},
+	}
+	indexData, err := json.Marshal(index)
+	if err != nil {
+		t.Fatalf("Failed to marshal index: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "index.json"), indexData, 0644); err != nil {
+		t.Fatalf("Failed to write index.json: %v", err)
+	}
+
+	image, err := LoadImage(tempDir)
+	if err != nil {
+		t.Fatalf("LoadImage failed: %v", err)
+	}
+
+	if image.Dir != tempDir {
+		t.Errorf("Dir = %q, expected %q", image.Dir, tempDir)
+	}
+	if len(image.Layers) != 2 {
+		t.Fatalf("len(Layers) = %d, expected 2", len(image.Layers))
+	}
+
+	if image.Layers[0].History != "COPY one /one" {
+		t.Errorf("Layers[0].Command = %q, expected %q (FROM scratch's empty layer should be skipped)", image.Layers[0].History, "COPY one /one")
+	}
+	if image.Layers[1].History != "COPY two /two" {
+		t.Errorf("Layers[1].Command = %q, expected %q", image.Layers[1].History, "COPY two /two")
+	}
+
+	wantExtractedDir := image.Layers[0].Path + ".extracted"
+	if image.Layers[0].ExtractedDir != wantExtractedDir {
+		t.Errorf("Layers[0].ExtractedDir = %q, expected %q", image.Layers[0].ExtractedDir, wantExtractedDir)
+	}
+}
@@ -0,0 +1,273 @@
+package docker
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Layer is a single image layer in manifest order, as enumerated by
+// ReadImageLayout or ReadBlobLayout.
+type Layer struct {
+	// Digest is the layer's content address ("sha256:<hex>") for OCI Image
+	// Layout / Docker v1.2 images, or the layer directory name for the
+	// legacy Docker v1 `docker save` layout.
+	Digest string
+	// Path is the absolute path to the layer's tarball on disk.
+	Path string
+	// ExtractedDir is the directory this layer's contents were (or would
+	// be) extracted into, in the same convention extractLayers uses. It is
+	// only populated by LoadImage.
+	ExtractedDir string
+	// History is the Dockerfile instruction ("created_by" in the image
+	// config) that produced this layer, e.g. "COPY . /app" or
+	// "RUN pip install -r requirements.txt". It is only populated by
+	// LoadImage, and is empty if the image config has no history entry for
+	// this layer.
+	History string
+}
+
+// ociIndex mirrors the subset of an OCI Image Layout index.json needed to
+// locate the image manifest blob.
+type ociIndex struct {
+	Manifests []struct {
+		Digest string `json:"digest"`
+	} `json:"manifests"`
+}
+
+// ociManifest mirrors the subset of an OCI/Docker v1.2 image manifest blob
+// needed to enumerate layer digests and locate the image config blob.
+type ociManifest struct {
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	Layers []struct {
+		Digest string `json:"digest"`
+	} `json:"layers"`
+}
+
+// dockerManifest mirrors the subset of a Docker `save` manifest.json needed
+// to enumerate layer paths and locate the image config.
+type dockerManifest struct {
+	Config string   `json:"Config"`
+	Layers []string `json:"Layers"`
+}
+
+// ImageConfig mirrors the subset of an OCI/Docker image config JSON needed
+// to verify layer integrity: the expected uncompressed digest ("diffID")
+// for each layer, in the same order as the manifest's Layers.
+type ImageConfig struct {
+	RootFS struct {
+		DiffIDs []string `json:"diff_ids"`
+	} `json:"rootfs"`
+	// History is the Dockerfile build history, one entry per image layer in
+	// the Dockerfile's instruction order, including instructions that
+	// produced no layer (EmptyLayer true), which must be skipped when
+	// pairing entries positionally against Layers. Used by LoadImage to
+	// attribute each Layer to the instruction that created it.
+	History []struct {
+		CreatedBy  string `json:"created_by"`
+		EmptyLayer bool   `json:"empty_layer"`
+	} `json:"history"`
+}
+
+// blobPath resolves a "sha256:<hex>" digest to its path under imageDir's
+// blobs/sha256/ directory.
+func blobPath(imageDir, digest string) (string, error) {
+	const prefix = "sha256:"
+	if !strings.HasPrefix(digest, prefix) {
+		return "", fmt.Errorf("unsupported digest algorithm: %s", digest)
+	}
+	return filepath.Join(imageDir, "blobs", "sha256", strings.TrimPrefix(digest, prefix)), nil
+}
+
+// ReadBlobLayout enumerates the layers of an extracted image directory that
+// uses content-addressed blobs: either the OCI Image Layout (index.json
+// referencing a manifest blob under blobs/sha256/) or a Docker v1.2
+// manifest.json whose Layers already point into blobs/sha256/. It returns an
+// error if imageDir uses neither layout, so callers can fall back to the
+// legacy Docker v1 layer.tar tree.
+func ReadBlobLayout(imageDir string) ([]Layer, error) {
+	if layers, err := readOCIIndex(imageDir); err == nil {
+		return layers, nil
+	}
+	return readDockerBlobManifest(imageDir)
+}
+
+// ReadImageLayout enumerates the layers of an extracted Docker/OCI image
+// directory in manifest order, trying the content-addressed layouts handled
+// by ReadBlobLayout first and falling back to the legacy Docker v1 layout
+// ("<layer-id>/layer.tar") when neither index.json nor manifest.json is
+// present.
+func ReadImageLayout(imageDir string) ([]Layer, error) {
+	if layers, err := ReadBlobLayout(imageDir); err == nil {
+		return layers, nil
+	}
+	return readLegacyLayerTars(imageDir)
+}
+
+// ReadBlobConfig reads the image config referenced by an OCI Image Layout or
+// Docker v1.2 manifest.json and returns its RootFS diff IDs, in the same
+// order as the layers returned by ReadBlobLayout. It returns an error if
+// imageDir does not use a content-addressed layout or has no readable
+// config, so callers should treat integrity verification as best-effort.
+func ReadBlobConfig(imageDir string) (*ImageConfig, error) {
+	if cfg, err := readOCIConfig(imageDir); err == nil {
+		return cfg, nil
+	}
+	return readDockerBlobConfig(imageDir)
+}
+
+func readOCIConfig(imageDir string) (*ImageConfig, error) {
+	data, err := os.ReadFile(filepath.Join(imageDir, "index.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var index ociIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse index.json: %v", err)
+	}
+	if len(index.Manifests) == 0 {
+		return nil, fmt.Errorf("index.json contains no manifests")
+	}
+
+	manifestPath, err := blobPath(imageDir, index.Manifests[0].Digest)
+	if err != nil {
+		return nil, err
+	}
+	manifestData, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image manifest blob: %v", err)
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse image manifest blob: %v", err)
+	}
+	if manifest.Config.Digest == "" {
+		return nil, fmt.Errorf("image manifest blob has no config digest")
+	}
+
+	configPath, err := blobPath(imageDir, manifest.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	return readImageConfigFile(configPath)
+}
+
+func readDockerBlobConfig(imageDir string) (*ImageConfig, error) {
+	data, err := os.ReadFile(filepath.Join(imageDir, "manifest.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []dockerManifest
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest.json: %v", err)
+	}
+	if len(manifests) == 0 || manifests[0].Config == "" {
+		return nil, fmt.Errorf("manifest.json has no config reference")
+	}
+
+	return readImageConfigFile(filepath.Join(imageDir, manifests[0].Config))
+}
+
+func readImageConfigFile(path string) (*ImageConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image config: %v", err)
+	}
+	var cfg ImageConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse image config: %v", err)
+	}
+	return &cfg, nil
+}
+
+func readOCIIndex(imageDir string) ([]Layer, error) {
+	data, err := os.ReadFile(filepath.Join(imageDir, "index.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var index ociIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil, fmt.Errorf("failed to parse index.json: %v", err)
+	}
+	if len(index.Manifests) == 0 {
+		return nil, fmt.Errorf("index.json contains no manifests")
+	}
+
+	manifestPath, err := blobPath(imageDir, index.Manifests[0].Digest)
+	if err != nil {
+		return nil, err
+	}
+	manifestData, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image manifest blob: %v", err)
+	}
+
+	var manifest ociManifest
+	if err := json.Unmarshal(manifestData, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse image manifest blob: %v", err)
+	}
+
+	layers := make([]Layer, len(manifest.Layers))
+	for i, l := range manifest.Layers {
+		path, err := blobPath(imageDir, l.Digest)
+		if err != nil {
+			return nil, err
+		}
+		layers[i] = Layer{Digest: l.Digest, Path: path}
+	}
+	return layers, nil
+}
+
+func readDockerBlobManifest(imageDir string) ([]Layer, error) {
+	data, err := os.ReadFile(filepath.Join(imageDir, "manifest.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	var manifests []dockerManifest
+	if err := json.Unmarshal(data, &manifests); err != nil {
+		return nil, fmt.Errorf("failed to parse manifest.json: %v", err)
+	}
+	if len(manifests) == 0 {
+		return nil, fmt.Errorf("manifest.json contains no images")
+	}
+
+	blobsPrefix := filepath.Join("blobs", "sha256") + string(filepath.Separator)
+	layers := make([]Layer, 0, len(manifests[0].Layers))
+	for _, l := range manifests[0].Layers {
+		if !strings.HasPrefix(filepath.ToSlash(l), "blobs/sha256/") && !strings.HasPrefix(l, blobsPrefix) {
+			return nil, fmt.Errorf("manifest.json does not reference content-addressed blobs")
+		}
+		layers = append(layers, Layer{
+			Digest: "sha256:" + filepath.Base(l),
+			Path:   filepath.Join(imageDir, l),
+		})
+	}
+	return layers, nil
+}
+
+func readLegacyLayerTars(imageDir string) ([]Layer, error) {
+	matches, err := filepath.Glob(filepath.Join(imageDir, "*/layer.tar"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find layer tarballs: %v", err)
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no layer tarballs found under %s", imageDir)
+	}
+	sort.Strings(matches)
+
+	layers := make([]Layer, len(matches))
+	for i, m := range matches {
+		layers[i] = Layer{Digest: filepath.Base(filepath.Dir(m)), Path: m}
+	}
+	return layers, nil
+}
@@ -0,0 +1,124 @@
+package docker
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeBlob writes content under imageDir/blobs/sha256/<hex> and returns the
+// "sha256:<hex>" digest for it.
+func writeBlob(t *testing.T, imageDir string, hex string, content []byte) string {
+	t.Helper()
+	blobsDir := filepath.Join(imageDir, "blobs", "sha256")
+	if err := os.MkdirAll(blobsDir, 0755); err != nil {
+		t.Fatalf("Failed to create blobs directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(blobsDir, hex), content, 0644); err != nil {
+		t.Fatalf("Failed to write blob %q: %v", hex, err)
+	}
+	return "sha256:" + hex
+}
+
+func TestReadImageLayoutOCI(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "oci-layout-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	layer1Digest := writeBlob(t, tempDir, "1111", []byte("layer one content"))
+	layer2Digest := writeBlob(t, tempDir, "2222", []byte("layer two content"))
+
+	manifest := ociManifest{
+		Layers: []struct {
+			Digest string `json:"digest"`
+		}{
+			{Digest: layer1Digest},
+			{Digest: layer2Digest},
+		},
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatalf("Failed to marshal manifest: %v", err)
+	}
+	manifestDigest := writeBlob(t, tempDir, "manifest", manifestData)
+
+	index := ociIndex{
+		Manifests: []struct {
+			Digest string `json:"digest"`
+		}{
+			{Digest: manifestDigest},
+		},
+	}
+	indexData, err := json.Marshal(index)
+	if err != nil {
+		t.Fatalf("Failed to marshal index: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(tempDir, "index.json"), indexData, 0644); err != nil {
+		t.Fatalf("Failed to write index.json: %v", err)
+	}
+
+	layers, err := ReadImageLayout(tempDir)
+	if err != nil {
+		t.Fatalf("ReadImageLayout failed: %v", err)
+	}
+
+	if len(layers) != 2 {
+		t.Fatalf("len(layers) = %d, expected 2", len(layers))
+	}
+	if layers[0].Digest != layer1Digest {
+		t.Errorf("layers[0].Digest = %q, expected %q", layers[0].Digest, layer1Digest)
+	}
+	if layers[1].Digest != layer2Digest {
+		t.Errorf("layers[1].Digest = %q, expected %q", layers[1].Digest, layer2Digest)
+	}
+	if _, err := os.Stat(layers[0].Path); err != nil {
+		t.Errorf("layers[0].Path = %q does not exist: %v", layers[0].Path, err)
+	}
+}
+
+func TestReadImageLayoutLegacyFallback(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "legacy-layout-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	layerDirs := []string{"layer1", "layer2"}
+	for _, dir := range layerDirs {
+		dirPath := filepath.Join(tempDir, dir)
+		if err := os.MkdirAll(dirPath, 0755); err != nil {
+			t.Fatalf("Failed to create layer directory %q: %v", dirPath, err)
+		}
+		if err := os.WriteFile(filepath.Join(dirPath, "layer.tar"), []byte("content for "+dir), 0644); err != nil {
+			t.Fatalf("Failed to write layer.tar: %v", err)
+		}
+	}
+
+	layers, err := ReadImageLayout(tempDir)
+	if err != nil {
+		t.Fatalf("ReadImageLayout failed: %v", err)
+	}
+	if len(layers) != len(layerDirs) {
+		t.Fatalf("len(layers) = %d, expected %d", len(layers), len(layerDirs))
+	}
+	for i, dir := range layerDirs {
+		if layers[i].Digest != dir {
+			t.Errorf("layers[%d].Digest = %q, expected %q", i, layers[i].Digest, dir)
+		}
+	}
+}
+
+func TestReadBlobLayoutErrorsWithoutBlobLayout(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "no-layout-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	if _, err := ReadBlobLayout(tempDir); err == nil {
+		t.Errorf("ReadBlobLayout with no index.json/manifest.json should fail")
+	}
+}
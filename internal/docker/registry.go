@@ -0,0 +1,79 @@
+package docker
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/layout"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// PullOptions configures PullImage.
+type PullOptions struct {
+	// OS, Arch, and Variant select a single platform from a multi-arch
+	// image's manifest list. Left empty, the registry's default platform
+	// resolution applies.
+	OS      string
+	Arch    string
+	Variant string
+	// InsecureSkipTLSVerify disables TLS certificate verification, for
+	// registries running with self-signed certificates.
+	InsecureSkipTLSVerify bool
+}
+
+// PullImage fetches ref from any OCI-compliant registry using
+// go-containerregistry, honoring ~/.docker/config.json credentials via
+// authn.DefaultKeychain, and writes it to destDir as an OCI Image Layout
+// (oci-layout, index.json, blobs/sha256/...) readable by ReadImageLayout.
+// Unlike ExtractImage's daemon-backed path, this does not require a local
+// Docker daemon.
+func PullImage(ref string, destDir string, opts PullOptions) (string, error) {
+	tag, err := name.ParseReference(ref)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse image reference %q: %v", ref, err)
+	}
+
+	transport := http.DefaultTransport
+	if opts.InsecureSkipTLSVerify {
+		transport = &http.Transport{TLSClientConfig: &tls.Config{InsecureSkipVerify: true}}
+	}
+
+	remoteOpts := []remote.Option{
+		remote.WithAuthFromKeychain(authn.DefaultKeychain),
+		remote.WithTransport(transport),
+	}
+	if opts.OS != "" || opts.Arch != "" || opts.Variant != "" {
+		remoteOpts = append(remoteOpts, remote.WithPlatform(v1.Platform{
+			OS:           opts.OS,
+			Architecture: opts.Arch,
+			Variant:      opts.Variant,
+		}))
+	}
+
+	img, err := remote.Image(tag, remoteOpts...)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch image %q: %v", ref, err)
+	}
+
+	imageDir := filepath.Join(destDir, "image")
+	if err := os.MkdirAll(imageDir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create image directory: %v", err)
+	}
+
+	lp, err := layout.Write(imageDir, empty.Index)
+	if err != nil {
+		return "", fmt.Errorf("failed to initialize OCI image layout: %v", err)
+	}
+	if err := lp.AppendImage(img); err != nil {
+		return "", fmt.Errorf("failed to write image to OCI layout: %v", err)
+	}
+
+	return imageDir, nil
+}
@@ -0,0 +1,31 @@
+package docker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestPullImageInvalidReference is a simplified test for PullImage's error
+// path. Pulling a real image would require network access to a registry, so
+// we only exercise reference parsing here.
+func TestPullImageInvalidReference(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "pull-image-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	destDir := filepath.Join(tempDir, "dest")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	_, err = PullImage("INVALID::not-a-reference", destDir, PullOptions{})
+	if err == nil {
+		t.Errorf("PullImage with an invalid reference should fail")
+	}
+
+	// Note: we can't fully test PullImage without network access to a real
+	// registry, so we'll skip the actual pull test.
+}
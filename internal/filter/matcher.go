@@ -0,0 +1,192 @@
+// Package filter implements the shared path/extension/content matching
+// used to apply a scallop policy's exclude/include rules consistently
+// across every walker in the analyzer package.
+package filter
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Matcher tests paths and file content against a set of exclude/include
+// rules: exclude_paths/exclude_extensions/exclude_strings/include_paths
+// from a policy file, plus any gitignore-style patterns loaded from a
+// .scallopignore file. A nil *Matcher excludes nothing, so callers that
+// don't use a Policy can pass nil without a special case.
+type Matcher struct {
+	excludePaths      []string
+	excludeExtensions map[string]bool
+	excludeStrings    []string
+	includePaths      []string
+	ignorePatterns    []string
+}
+
+// New builds a Matcher from a policy's raw pattern lists. Patterns may use
+// the literal token "{sep}" for the OS path separator, so a rule like
+// "{sep}usr{sep}lib" reads the same in a policy file on Linux and Windows,
+// and "**" to match any number of path segments, which filepath.Match alone
+// does not support.
+func New(excludePaths, excludeExtensions, excludeStrings, includePaths []string) *Matcher {
+	exts := make(map[string]bool, len(excludeExtensions))
+	for _, e := range excludeExtensions {
+		exts[strings.ToLower(e)] = true
+	}
+	return &Matcher{
+		excludePaths:      expandSep(excludePaths),
+		excludeExtensions: exts,
+		excludeStrings:    excludeStrings,
+		includePaths:      expandSep(includePaths),
+	}
+}
+
+// expandSep replaces the "{sep}" token in every pattern with the OS path
+// separator.
+func expandSep(patterns []string) []string {
+	out := make([]string, len(patterns))
+	for i, p := range patterns {
+		out[i] = strings.ReplaceAll(p, "{sep}", string(filepath.Separator))
+	}
+	return out
+}
+
+// LoadIgnoreFile adds the patterns from a gitignore-style ignore file (e.g.
+// .scallopignore) found at filepath.Join(root, name) to m, layering on top
+// of any exclude_paths already configured. It is a no-op, not an error, if
+// the file doesn't exist.
+//
+// Only a pragmatic subset of gitignore syntax is supported: one glob
+// pattern per line, blank lines and "#" comments skipped. Negation ("!")
+// and directory-only trailing-slash patterns are not implemented; treat
+// this the same way as compareVersions' dotted-numeric comparison in
+// vulnscan.go - an approximation that covers the common case, not a
+// drop-in gitignore parser.
+func (m *Matcher) LoadIgnoreFile(root, name string) error {
+	data, err := os.ReadFile(filepath.Join(root, name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", name, err)
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		m.ignorePatterns = append(m.ignorePatterns, line)
+	}
+	return nil
+}
+
+// ExcludesPath reports whether relPath should be dropped: its extension is
+// in excludeExtensions, or it matches an exclude_paths/.scallopignore
+// pattern, and it isn't rescued by a more specific include_paths pattern.
+func (m *Matcher) ExcludesPath(relPath string) bool {
+	if m == nil {
+		return false
+	}
+
+	excluded := m.excludeExtensions[strings.ToLower(filepath.Ext(relPath))]
+	if !excluded {
+		for _, p := range m.excludePaths {
+			if matchGlob(p, relPath) {
+				excluded = true
+				break
+			}
+		}
+	}
+	if !excluded {
+		for _, p := range m.ignorePatterns {
+			if matchGlob(p, relPath) {
+				excluded = true
+				break
+			}
+		}
+	}
+	if !excluded {
+		return false
+	}
+
+	for _, p := range m.includePaths {
+		if matchGlob(p, relPath) {
+			return false
+		}
+	}
+	return true
+}
+
+// ExcludesString reports whether content contains one of excludeStrings, so
+// callers can suppress a match that is a known-safe placeholder rather than
+// a real finding.
+func (m *Matcher) ExcludesString(content string) bool {
+	if m == nil {
+		return false
+	}
+	for _, s := range m.excludeStrings {
+		if strings.Contains(content, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob reports whether relPath matches pattern. It extends
+// filepath.Match with "**" (matching any number of path segments,
+// including none) and, for a pattern with no glob metacharacters at all,
+// falls back to a plain substring check so a rule like "{sep}usr{sep}lib"
+// matches anywhere in the path rather than requiring an exact match.
+func matchGlob(pattern, relPath string) bool {
+	pattern = filepath.ToSlash(pattern)
+	relPath = filepath.ToSlash(relPath)
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		return strings.Contains(relPath, pattern)
+	}
+
+	if !strings.Contains(pattern, "**") {
+		if ok, _ := filepath.Match(pattern, relPath); ok {
+			return true
+		}
+		ok, _ := filepath.Match(pattern, filepath.Base(relPath))
+		return ok
+	}
+
+	return doubleStarRegexp(pattern).MatchString(relPath)
+}
+
+// doubleStarRegexp compiles a "**"-aware glob pattern into a regexp: "**/"
+// matches zero or more whole path segments, a bare "**" matches anything
+// (including "/"), "*" matches within a single segment, and "?" matches a
+// single non-separator character.
+func doubleStarRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); {
+		switch {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 3
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i += 2
+		case pattern[i] == '*':
+			b.WriteString("[^/]*")
+			i++
+		case pattern[i] == '?':
+			b.WriteString("[^/]")
+			i++
+		case strings.ContainsRune(`.()+|^$\`, rune(pattern[i])):
+			b.WriteString(regexp.QuoteMeta(string(pattern[i])))
+			i++
+		default:
+			b.WriteByte(pattern[i])
+			i++
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
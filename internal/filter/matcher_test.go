@@ -0,0 +1,87 @@
+package filter
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMatcherExcludesPath(t *testing.T) {
+	m := New([]string{"{sep}usr{sep}lib", "**/*.min.js"}, nil, nil, []string{"**/keep.min.js"})
+
+	cases := []struct {
+		path string
+		want bool
+	}{
+		{"rootfs/usr/lib/libc.so", true},
+		{"app/vendor.min.js", true},
+		{"app/keep.min.js", false}, // rescued by include_paths
+		{"app/main.js", false},
+	}
+
+	for _, c := range cases {
+		if got := m.ExcludesPath(c.path); got != c.want {
+			t.Errorf("ExcludesPath(%q) = %v, expected %v", c.path, got, c.want)
+		}
+	}
+}
+
+func TestMatcherExcludesStringAndNil(t *testing.T) {
+	m := New(nil, nil, []string{"EXAMPLEKEY"}, nil)
+	if !m.ExcludesString("const key = 'AKIAEXAMPLEKEY';") {
+		t.Errorf("expected a line containing an excluded string to be excluded")
+	}
+	if m.ExcludesString("const key = 'somethingelse';") {
+		t.Errorf("expected a line without an excluded string to not be excluded")
+	}
+
+	var nilMatcher *Matcher
+	if nilMatcher.ExcludesPath("anything") {
+		t.Errorf("a nil Matcher should exclude nothing")
+	}
+	if nilMatcher.ExcludesString("anything") {
+		t.Errorf("a nil Matcher should exclude nothing")
+	}
+}
+
+func TestMatcherDoubleStarGlob(t *testing.T) {
+	if !matchGlob("**/*.min.js", "a/b/c/vendor.min.js") {
+		t.Errorf("expected ** to match across multiple directories")
+	}
+	if matchGlob("**/*.min.js", "a/b/c/vendor.js") {
+		t.Errorf("expected *.min.js to require the .min.js suffix")
+	}
+}
+
+func TestMatcherLoadIgnoreFile(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "scallopignore-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	ignoreContent := "# comment\n\nnode_modules/**\n*.log\n"
+	if err := os.WriteFile(filepath.Join(tempDir, ".scallopignore"), []byte(ignoreContent), 0644); err != nil {
+		t.Fatalf("Failed to write .scallopignore: %v", err)
+	}
+
+	m := New(nil, nil, nil, nil)
+	if err := m.LoadIgnoreFile(tempDir, ".scallopignore"); err != nil {
+		t.Fatalf("LoadIgnoreFile failed: %v", err)
+	}
+
+	if !m.ExcludesPath("node_modules/lodash/index.js") {
+		t.Errorf("expected node_modules/** to exclude a file under node_modules")
+	}
+	if !m.ExcludesPath("app/debug.log") {
+		t.Errorf("expected *.log to exclude a .log file")
+	}
+	if m.ExcludesPath("app/main.js") {
+		t.Errorf("main.js should not be excluded")
+	}
+
+	empty := New(nil, nil, nil, nil)
+	if err := empty.LoadIgnoreFile(tempDir, ".does-not-exist"); err != nil {
+		t.Errorf("LoadIgnoreFile with a missing file should not error, got %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package sbom
+
+import (
+	"encoding/json"
+	"io"
+	"strconv"
+)
+
+// cyclonedxDocument is the subset of the CycloneDX 1.5 JSON schema
+// (https://cyclonedx.org/docs/1.5/json/) WriteCycloneDX populates.
+type cyclonedxDocument struct {
+	BOMFormat   string          `json:"bomFormat"`
+	SpecVersion string          `json:"specVersion"`
+	Version     int             `json:"version"`
+	Components  []cyclonedxComp `json:"components"`
+}
+
+type cyclonedxComp struct {
+	Type       string              `json:"type"`
+	BOMRef     string              `json:"bom-ref"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version"`
+	PURL       string              `json:"purl,omitempty"`
+	Hashes     []cyclonedxHash     `json:"hashes,omitempty"`
+	Properties []cyclonedxProperty `json:"properties,omitempty"`
+}
+
+type cyclonedxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cyclonedxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// WriteCycloneDX renders doc as a CycloneDX 1.5 BOM in JSON to w. Every
+// component's bom-ref is its Component.BOMRef, so a SecurityIssue that
+// references one (once SecurityResult embeds the Document this came from)
+// resolves to the same component a tool like Dependency-Track or Grype
+// would load from this same file.
+func WriteCycloneDX(doc *Document, w io.Writer) error {
+	out := cyclonedxDocument{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: "1.5",
+		Version:     1,
+		Components:  make([]cyclonedxComp, 0, len(doc.Components)),
+	}
+
+	for _, c := range doc.Components {
+		comp := cyclonedxComp{
+			Type:    "library",
+			BOMRef:  c.BOMRef,
+			Name:    c.Name,
+			Version: c.Version,
+			PURL:    c.PURL,
+			Properties: []cyclonedxProperty{
+				{Name: "scallop:ecosystem", Value: c.Ecosystem},
+				{Name: "scallop:source", Value: c.Source},
+			},
+		}
+		if c.LayerDiffID != "" {
+			comp.Properties = append(comp.Properties,
+				cyclonedxProperty{Name: "scallop:layerIndex", Value: strconv.Itoa(c.LayerIndex)},
+				cyclonedxProperty{Name: "scallop:layerDiffId", Value: c.LayerDiffID},
+			)
+		}
+		for alg, content := range c.Hashes {
+			comp.Hashes = append(comp.Hashes, cyclonedxHash{Alg: alg, Content: content})
+		}
+		out.Components = append(out.Components, comp)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,48 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteCycloneDX(t *testing.T) {
+	doc := &Document{Components: []Component{
+		{
+			BOMRef: "pkg:npm/lodash@4.17.21", Name: "lodash", Version: "4.17.21",
+			Ecosystem: "npm", PURL: "pkg:npm/lodash@4.17.21", Source: "package.json",
+			Hashes: map[string]string{"SHA-256": "abc123"},
+		},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteCycloneDX(doc, &buf); err != nil {
+		t.Fatalf("WriteCycloneDX failed: %v", err)
+	}
+
+	var out struct {
+		BOMFormat   string `json:"bomFormat"`
+		SpecVersion string `json:"specVersion"`
+		Components  []struct {
+			BOMRef string `json:"bom-ref"`
+			PURL   string `json:"purl"`
+			Hashes []struct {
+				Alg     string `json:"alg"`
+				Content string `json:"content"`
+			} `json:"hashes"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("failed to unmarshal output: %v", err)
+	}
+
+	if out.BOMFormat != "CycloneDX" || out.SpecVersion != "1.5" {
+		t.Errorf("unexpected header: %+v", out)
+	}
+	if len(out.Components) != 1 || out.Components[0].BOMRef != "pkg:npm/lodash@4.17.21" {
+		t.Fatalf("unexpected components: %+v", out.Components)
+	}
+	if len(out.Components[0].Hashes) != 1 || out.Components[0].Hashes[0].Content != "abc123" {
+		t.Errorf("unexpected hashes: %+v", out.Components[0].Hashes)
+	}
+}
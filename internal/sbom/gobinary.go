@@ -0,0 +1,56 @@
+package sbom
+
+import (
+	"debug/buildinfo"
+	"os"
+	"path/filepath"
+)
+
+// enumerateGoBinaries walks imagePath for executable regular files and
+// reads each one's embedded Go module info via debug/buildinfo, reporting
+// every dependency module it lists. Non-Go binaries and non-executable
+// files are not even opened for this, since buildinfo.ReadFile has to
+// parse an ELF/PE/Mach-O header to tell whether a file is a Go binary at
+// all, and most of an image's files aren't.
+func enumerateGoBinaries(imagePath string) ([]Component, error) {
+	var components []Component
+
+	err := filepath.Walk(imagePath, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info.IsDir() || info.Mode()&0111 == 0 {
+			return nil
+		}
+
+		bi, err := buildinfo.ReadFile(path)
+		if err != nil {
+			return nil
+		}
+
+		source := relPath(imagePath, path)
+		hash, err := sha256File(path)
+		hashes := map[string]string(nil)
+		if err == nil {
+			hashes = map[string]string{"SHA-256": hash}
+		}
+
+		for _, dep := range bi.Deps {
+			mod := dep
+			if dep.Replace != nil {
+				mod = dep.Replace
+			}
+			components = append(components, Component{
+				Name:      mod.Path,
+				Version:   mod.Version,
+				Ecosystem: "Go",
+				PURL:      purl("Go", mod.Path, mod.Version),
+				Hashes:    hashes,
+				Source:    source,
+			})
+		}
+		return nil
+	})
+
+	return components, err
+}
@@ -0,0 +1,447 @@
+package sbom
+
+import (
+	"bufio"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// enumeratePackageJSON parses package.json's dependencies and
+// devDependencies for name/version pairs, stripping the semver range
+// prefixes ("^", "~") npm uses for pinned-looking versions.
+func enumeratePackageJSON(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "package.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var manifest struct {
+		Dependencies    map[string]string `json:"dependencies"`
+		DevDependencies map[string]string `json:"devDependencies"`
+	}
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, err
+	}
+
+	source := relPath(imagePath, path)
+
+	var components []Component
+	for name, version := range manifest.Dependencies {
+		version = strings.TrimLeft(version, "^~")
+		components = append(components, Component{Name: name, Version: version, Ecosystem: "npm", Source: source, PURL: purl("npm", name, version)})
+	}
+	for name, version := range manifest.DevDependencies {
+		version = strings.TrimLeft(version, "^~")
+		components = append(components, Component{Name: name, Version: version, Ecosystem: "npm", Source: source, PURL: purl("npm", name, version)})
+	}
+	return components, nil
+}
+
+// enumeratePackageLockJSON parses npm's package-lock.json for resolved
+// name/version pairs. It supports the lockfile v2/v3 "packages" map (keyed
+// by "node_modules/<name>" path, each value needing just its own name
+// re-derived from the key plus a "version" field), which is what current
+// npm generates; it does not fall back to the legacy v1 "dependencies" tree
+// that enumeratePackageJSON-style lockfiles predate.
+func enumeratePackageLockJSON(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "package-lock.json")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock struct {
+		Packages map[string]struct {
+			Version string `json:"version"`
+		} `json:"packages"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	source := relPath(imagePath, path)
+
+	var components []Component
+	for key, pkg := range lock.Packages {
+		if key == "" || pkg.Version == "" {
+			continue
+		}
+		idx := strings.LastIndex(key, "node_modules/")
+		if idx == -1 {
+			continue
+		}
+		name := key[idx+len("node_modules/"):]
+		components = append(components, Component{Name: name, Version: pkg.Version, Ecosystem: "npm", Source: source, PURL: purl("npm", name, pkg.Version)})
+	}
+	return components, nil
+}
+
+// yarnLockHeaderRe matches a yarn.lock entry header, e.g.
+// `lodash@^4.17.20, lodash@^4.17.21:` or `"@babel/core@^7.20.0":`.
+var yarnLockHeaderRe = regexp.MustCompile(`^"?(@?[^@"\s,]+)@`)
+
+// yarnLockVersionRe matches a yarn.lock entry's `  version "4.17.21"` line.
+var yarnLockVersionRe = regexp.MustCompile(`^\s+version\s+"([^"]+)"`)
+
+// enumerateYarnLock parses yarn.lock's resolved package versions: each
+// entry is a comma-separated list of "name@range" headers followed by an
+// indented "version" line giving the single resolved version all of them
+// share.
+func enumerateYarnLock(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "yarn.lock")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	source := relPath(imagePath, path)
+
+	var components []Component
+	var name string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if m := yarnLockHeaderRe.FindStringSubmatch(line); m != nil && !strings.HasPrefix(line, " ") {
+			name = m[1]
+			continue
+		}
+		if m := yarnLockVersionRe.FindStringSubmatch(line); m != nil && name != "" {
+			components = append(components, Component{Name: name, Version: m[1], Ecosystem: "npm", Source: source, PURL: purl("npm", name, m[1])})
+			name = ""
+		}
+	}
+	return components, scanner.Err()
+}
+
+// pnpmLockPackageRe matches a pnpm-lock.yaml package key under the
+// top-level "packages:" map, e.g. "/lodash@4.17.21:" (pre-v6 lockfiles) or
+// "lodash@4.17.21:" (v6+), including scoped names like "@babel/core@7.20.0".
+var pnpmLockPackageRe = regexp.MustCompile(`^\s*/?(@[^/]+/[^@]+|[^@/]+)@([^(:]+)\(?.*:\s*$`)
+
+// enumeratePnpmLock parses pnpm-lock.yaml's "packages:" section for
+// resolved name@version pairs. pnpm-lock.yaml is YAML, but this section's
+// keys are regular enough that a line-oriented regex avoids pulling in a
+// YAML library just for this one format.
+func enumeratePnpmLock(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "pnpm-lock.yaml")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	source := relPath(imagePath, path)
+
+	var components []Component
+	inPackages := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "packages:") {
+			inPackages = true
+			continue
+		}
+		if inPackages && line != "" && !strings.HasPrefix(line, " ") {
+			inPackages = false
+		}
+		if !inPackages {
+			continue
+		}
+		if m := pnpmLockPackageRe.FindStringSubmatch(line); m != nil {
+			name, version := m[1], m[2]
+			components = append(components, Component{Name: name, Version: version, Ecosystem: "npm", Source: source, PURL: purl("npm", name, version)})
+		}
+	}
+	return components, scanner.Err()
+}
+
+// goSumVersionRe matches a "module version" pair on a go.sum line, ignoring
+// the trailing "/go.mod h1:..." duplicate entries.
+var goSumVersionRe = regexp.MustCompile(`^(\S+)\s+(v\S+)/go\.mod\s+h1:`)
+
+// enumerateGoSum parses go.sum for module@version pairs.
+func enumerateGoSum(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "go.sum")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	source := relPath(imagePath, path)
+
+	var components []Component
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if m := goSumVersionRe.FindStringSubmatch(scanner.Text()); m != nil {
+			components = append(components, Component{Name: m[1], Version: m[2], Ecosystem: "Go", Source: source, PURL: purl("Go", m[1], m[2])})
+		}
+	}
+	return components, scanner.Err()
+}
+
+// enumerateRequirementsTxt parses requirements.txt for name==version pins.
+func enumerateRequirementsTxt(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "requirements.txt")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	source := relPath(imagePath, path)
+
+	var components []Component
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		parts := strings.SplitN(line, "==", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		name := strings.TrimSpace(parts[0])
+		version := strings.TrimSpace(parts[1])
+		components = append(components, Component{Name: name, Version: version, Ecosystem: "PyPI", Source: source, PURL: purl("PyPI", name, version)})
+	}
+	return components, scanner.Err()
+}
+
+// enumeratePipfileLock parses Pipfile.lock's "default" and "develop"
+// sections, each a map of package name to {"version": "==<version>", ...}.
+func enumeratePipfileLock(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "Pipfile.lock")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var lock struct {
+		Default map[string]struct {
+			Version string `json:"version"`
+		} `json:"default"`
+		Develop map[string]struct {
+			Version string `json:"version"`
+		} `json:"develop"`
+	}
+	if err := json.Unmarshal(data, &lock); err != nil {
+		return nil, err
+	}
+
+	source := relPath(imagePath, path)
+
+	var components []Component
+	for _, section := range []map[string]struct {
+		Version string `json:"version"`
+	}{lock.Default, lock.Develop} {
+		for name, pkg := range section {
+			version := strings.TrimPrefix(pkg.Version, "==")
+			if version == "" {
+				continue
+			}
+			components = append(components, Component{Name: name, Version: version, Ecosystem: "PyPI", Source: source, PURL: purl("PyPI", name, version)})
+		}
+	}
+	return components, nil
+}
+
+// enumeratePoetryLock parses poetry.lock's "[[package]]" tables for their
+// name/version fields.
+func enumeratePoetryLock(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "poetry.lock")
+	components, err := parseTOMLPackageTables(path, "PyPI")
+	if err != nil {
+		return nil, err
+	}
+	source := relPath(imagePath, path)
+	for i := range components {
+		components[i].Source = source
+	}
+	return components, nil
+}
+
+// enumerateCargoLock parses Cargo.lock's "[[package]]" tables the same way
+// poetry.lock's are parsed; both are TOML array-of-tables with plain
+// name/version string fields.
+func enumerateCargoLock(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "Cargo.lock")
+	components, err := parseTOMLPackageTables(path, "crates.io")
+	if err != nil {
+		return nil, err
+	}
+	source := relPath(imagePath, path)
+	for i := range components {
+		components[i].Source = source
+	}
+	return components, nil
+}
+
+// tomlStringFieldRe matches a simple `key = "value"` TOML line.
+var tomlStringFieldRe = regexp.MustCompile(`^(\w+)\s*=\s*"([^"]*)"`)
+
+// parseTOMLPackageTables scans a TOML file (Cargo.lock, poetry.lock) for
+// "[[package]]" array-of-tables, collecting each one's "name" and
+// "version" string fields. This is not a general TOML parser: it only
+// understands the flat name/version pairs these two lockfile formats use,
+// the same pragmatic, documented-approximation approach the repo already
+// takes for gitignore and glob parsing in internal/filter.
+func parseTOMLPackageTables(path, ecosystem string) ([]Component, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var components []Component
+	var name, version string
+	inPackage := false
+
+	flush := func() {
+		if name != "" && version != "" {
+			components = append(components, Component{Name: name, Version: version, Ecosystem: ecosystem, PURL: purl(ecosystem, name, version)})
+		}
+		name, version = "", ""
+	}
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "[[package]]":
+			if inPackage {
+				flush()
+			}
+			inPackage = true
+		case strings.HasPrefix(line, "[") && line != "[[package]]":
+			if inPackage {
+				flush()
+			}
+			inPackage = false
+		case inPackage:
+			if m := tomlStringFieldRe.FindStringSubmatch(line); m != nil {
+				switch m[1] {
+				case "name":
+					name = m[2]
+				case "version":
+					version = m[2]
+				}
+			}
+		}
+	}
+	if inPackage {
+		flush()
+	}
+	return components, scanner.Err()
+}
+
+// pomDependency is one <dependency> entry in a Maven pom.xml.
+type pomDependency struct {
+	GroupID    string `xml:"groupId"`
+	ArtifactID string `xml:"artifactId"`
+	Version    string `xml:"version"`
+}
+
+// pomProject is the subset of a Maven pom.xml's <project> this package
+// reads: the direct <dependencies> list. It does not resolve
+// <dependencyManagement> versions, parent POM inheritance, or property
+// placeholders (e.g. "${spring.version}") - only dependencies with a
+// literal <version> are reported.
+type pomProject struct {
+	Dependencies struct {
+		Dependency []pomDependency `xml:"dependency"`
+	} `xml:"dependencies"`
+}
+
+// enumeratePomXML parses pom.xml's direct <dependencies> for groupId/
+// artifactId/version triples, reported under Maven's "group:artifact" PURL
+// naming.
+func enumeratePomXML(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "pom.xml")
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var project pomProject
+	if err := xml.Unmarshal(data, &project); err != nil {
+		return nil, err
+	}
+
+	source := relPath(imagePath, path)
+
+	var components []Component
+	for _, dep := range project.Dependencies.Dependency {
+		if dep.Version == "" || strings.Contains(dep.Version, "${") {
+			continue
+		}
+		name := dep.GroupID + ":" + dep.ArtifactID
+		components = append(components, Component{Name: name, Version: dep.Version, Ecosystem: "Maven", Source: source, PURL: purl("Maven", name, dep.Version)})
+	}
+	return components, nil
+}
+
+// gradleLockfileRe matches a gradle.lockfile dependency line, e.g.
+// "com.google.guava:guava:31.1-jre=compileClasspath,runtimeClasspath".
+var gradleLockfileRe = regexp.MustCompile(`^([^:#\s]+):([^:\s]+):([^=\s]+)=`)
+
+// enumerateGradleLockfile parses a Gradle dependency-locking lockfile's
+// "group:artifact:version=<configurations>" lines.
+func enumerateGradleLockfile(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "gradle.lockfile")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	source := relPath(imagePath, path)
+
+	var components []Component
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := gradleLockfileRe.FindStringSubmatch(line); m != nil {
+			name := m[1] + ":" + m[2]
+			version := m[3]
+			components = append(components, Component{Name: name, Version: version, Ecosystem: "Maven", Source: source, PURL: purl("Maven", name, version)})
+		}
+	}
+	return components, scanner.Err()
+}
+
+// enumerateGemfileLockVersionRe matches a Gemfile.lock "    name (version)"
+// spec line.
+var gemfileLockVersionRe = regexp.MustCompile(`^\s{4}(\S+)\s+\(([^)]+)\)`)
+
+// enumerateGemfileLock parses the resolved gem versions out of a
+// Gemfile.lock's "specs:" section.
+func enumerateGemfileLock(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "Gemfile.lock")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	source := relPath(imagePath, path)
+
+	var components []Component
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if m := gemfileLockVersionRe.FindStringSubmatch(scanner.Text()); m != nil {
+			components = append(components, Component{Name: m[1], Version: m[2], Ecosystem: "RubyGems", Source: source, PURL: purl("RubyGems", m[1], m[2])})
+		}
+	}
+	return components, scanner.Err()
+}
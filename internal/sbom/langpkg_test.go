@@ -0,0 +1,117 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create directory for %s: %v", path, err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func TestEnumeratePackageLockJSON(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFile(t, filepath.Join(tempDir, "package-lock.json"), `{
+  "packages": {
+    "": {"name": "app", "version": "1.0.0"},
+    "node_modules/lodash": {"version": "4.17.21"},
+    "node_modules/foo/node_modules/lodash": {"version": "4.17.20"}
+  }
+}`)
+
+	components, err := enumeratePackageLockJSON(tempDir)
+	if err != nil {
+		t.Fatalf("enumeratePackageLockJSON failed: %v", err)
+	}
+
+	versions := make(map[string]bool)
+	for _, c := range components {
+		if c.Name != "lodash" {
+			t.Errorf("unexpected component name %q", c.Name)
+			continue
+		}
+		versions[c.Version] = true
+	}
+	if !versions["4.17.21"] || !versions["4.17.20"] {
+		t.Errorf("expected both lodash versions, got %v", versions)
+	}
+}
+
+func TestEnumeratePomXML(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFile(t, filepath.Join(tempDir, "pom.xml"), `<project>
+  <dependencies>
+    <dependency>
+      <groupId>com.google.guava</groupId>
+      <artifactId>guava</artifactId>
+      <version>31.1-jre</version>
+    </dependency>
+    <dependency>
+      <groupId>org.springframework</groupId>
+      <artifactId>spring-core</artifactId>
+      <version>${spring.version}</version>
+    </dependency>
+  </dependencies>
+</project>`)
+
+	components, err := enumeratePomXML(tempDir)
+	if err != nil {
+		t.Fatalf("enumeratePomXML failed: %v", err)
+	}
+	if len(components) != 1 {
+		t.Fatalf("expected 1 component (property-placeholder version skipped), got %d", len(components))
+	}
+	if components[0].Name != "com.google.guava:guava" || components[0].Version != "31.1-jre" {
+		t.Errorf("component = %+v, unexpected", components[0])
+	}
+	if want := "pkg:maven/com.google.guava/guava@31.1-jre"; components[0].PURL != want {
+		t.Errorf("PURL = %q, expected %q", components[0].PURL, want)
+	}
+}
+
+func TestEnumerateGradleLockfile(t *testing.T) {
+	tempDir := t.TempDir()
+	writeFile(t, filepath.Join(tempDir, "gradle.lockfile"), "# comment\ncom.google.guava:guava:31.1-jre=compileClasspath,runtimeClasspath\n")
+
+	components, err := enumerateGradleLockfile(tempDir)
+	if err != nil {
+		t.Fatalf("enumerateGradleLockfile failed: %v", err)
+	}
+	if len(components) != 1 || components[0].Name != "com.google.guava:guava" || components[0].Version != "31.1-jre" {
+		t.Fatalf("components = %+v, unexpected", components)
+	}
+}
+
+func TestParseTOMLPackageTables(t *testing.T) {
+	tempDir := t.TempDir()
+	path := filepath.Join(tempDir, "Cargo.lock")
+	writeFile(t, path, `[[package]]
+name = "serde"
+version = "1.0.152"
+
+[[package]]
+name = "libc"
+version = "0.2.139"
+`)
+
+	components, err := parseTOMLPackageTables(path, "crates.io")
+	if err != nil {
+		t.Fatalf("parseTOMLPackageTables failed: %v", err)
+	}
+	if len(components) != 2 {
+		t.Fatalf("expected 2 components, got %d", len(components))
+	}
+	if components[0].Name != "serde" || components[0].Version != "1.0.152" {
+		t.Errorf("components[0] = %+v, unexpected", components[0])
+	}
+	if components[1].Name != "libc" || components[1].Version != "0.2.139" {
+		t.Errorf("components[1] = %+v, unexpected", components[1])
+	}
+}
@@ -0,0 +1,107 @@
+package sbom
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// enumerateDpkg parses Debian's /var/lib/dpkg/status, which lists one
+// package per paragraph as "Package: name" / "Version: version" lines.
+func enumerateDpkg(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "var", "lib", "dpkg", "status")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	source := relPath(imagePath, path)
+
+	var components []Component
+	var name string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "Package: "):
+			name = strings.TrimPrefix(line, "Package: ")
+		case strings.HasPrefix(line, "Version: ") && name != "":
+			version := strings.TrimPrefix(line, "Version: ")
+			components = append(components, Component{
+				Name: name, Version: version, Ecosystem: "Debian", Source: source,
+				PURL: purl("Debian", name, version),
+			})
+			name = ""
+		}
+	}
+	return components, scanner.Err()
+}
+
+// enumerateApk parses Alpine's /lib/apk/db/installed, which lists one
+// package per paragraph as "P:name" / "V:version" lines.
+func enumerateApk(imagePath string) ([]Component, error) {
+	path := filepath.Join(imagePath, "lib", "apk", "db", "installed")
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	source := relPath(imagePath, path)
+
+	var components []Component
+	var name string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case strings.HasPrefix(line, "P:"):
+			name = strings.TrimPrefix(line, "P:")
+		case strings.HasPrefix(line, "V:") && name != "":
+			version := strings.TrimPrefix(line, "V:")
+			components = append(components, Component{
+				Name: name, Version: version, Ecosystem: "Alpine", Source: source,
+				PURL: purl("Alpine", name, version),
+			})
+			name = ""
+		}
+	}
+	return components, scanner.Err()
+}
+
+// enumerateRpm lists packages from an RPM database extracted at
+// var/lib/rpm by shelling out to the system "rpm" binary, the same way
+// analyzer.enumerateRpm does; on systems without rpm installed (or images
+// with no RPM database) it simply reports no packages.
+func enumerateRpm(imagePath string) ([]Component, error) {
+	dbPath := filepath.Join(imagePath, "var", "lib", "rpm")
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil, err
+	}
+
+	out, err := exec.Command("rpm", "--dbpath", dbPath, "-qa", "--qf", "%{NAME}\t%{VERSION}-%{RELEASE}\n").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rpm database: %v", err)
+	}
+
+	source := relPath(imagePath, dbPath)
+
+	var components []Component
+	scanner := bufio.NewScanner(bytes.NewReader(out))
+	for scanner.Scan() {
+		fields := strings.SplitN(scanner.Text(), "\t", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		components = append(components, Component{
+			Name: fields[0], Version: fields[1], Ecosystem: "Red Hat", Source: source,
+			PURL: purl("Red Hat", fields[0], fields[1]),
+		})
+	}
+	return components, scanner.Err()
+}
@@ -0,0 +1,53 @@
+package sbom
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// purl builds a Package URL (https://github.com/package-url/purl-spec) for
+// a component of the given ecosystem, or "" if ecosystem has no PURL type
+// this package maps to. Maven's group:artifact name is split into the
+// PURL's namespace/name parts; every other ecosystem is name@version
+// directly under its PURL type.
+func purl(ecosystem, name, version string) string {
+	escVersion := url.PathEscape(version)
+
+	switch ecosystem {
+	case "npm":
+		return fmt.Sprintf("pkg:npm/%s@%s", purlName(name), escVersion)
+	case "PyPI":
+		return fmt.Sprintf("pkg:pypi/%s@%s", purlName(strings.ToLower(name)), escVersion)
+	case "RubyGems":
+		return fmt.Sprintf("pkg:gem/%s@%s", purlName(name), escVersion)
+	case "Go":
+		return fmt.Sprintf("pkg:golang/%s@%s", purlName(name), escVersion)
+	case "crates.io":
+		return fmt.Sprintf("pkg:cargo/%s@%s", purlName(name), escVersion)
+	case "Debian":
+		return fmt.Sprintf("pkg:deb/debian/%s@%s", purlName(name), escVersion)
+	case "Alpine":
+		return fmt.Sprintf("pkg:apk/alpine/%s@%s", purlName(name), escVersion)
+	case "Red Hat":
+		return fmt.Sprintf("pkg:rpm/redhat/%s@%s", purlName(name), escVersion)
+	case "Maven":
+		if group, artifact, ok := strings.Cut(name, ":"); ok {
+			return fmt.Sprintf("pkg:maven/%s/%s@%s", purlName(group), purlName(artifact), escVersion)
+		}
+		return fmt.Sprintf("pkg:maven/%s@%s", purlName(name), escVersion)
+	default:
+		return ""
+	}
+}
+
+// purlName percent-encodes a PURL name component, preserving the "/"
+// scoped-package separator (e.g. npm's "@babel/core") rather than escaping
+// it into "%2F".
+func purlName(name string) string {
+	parts := strings.Split(name, "/")
+	for i, p := range parts {
+		parts[i] = url.PathEscape(p)
+	}
+	return strings.Join(parts, "/")
+}
@@ -0,0 +1,58 @@
+package sbom
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	tempDir := t.TempDir()
+
+	dpkgDir := filepath.Join(tempDir, "var", "lib", "dpkg")
+	if err := os.MkdirAll(dpkgDir, 0755); err != nil {
+		t.Fatalf("failed to create dpkg directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dpkgDir, "status"), []byte("Package: openssl\nVersion: 1.1.1n-0+deb11u1\n"), 0644); err != nil {
+		t.Fatalf("failed to write dpkg status: %v", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(tempDir, "package.json"), []byte(`{"dependencies":{"lodash":"^4.17.20"}}`), 0644); err != nil {
+		t.Fatalf("failed to write package.json: %v", err)
+	}
+
+	doc, err := Generate(tempDir)
+	if err != nil {
+		t.Fatalf("Generate failed: %v", err)
+	}
+
+	want := map[string]string{
+		"openssl": "pkg:deb/debian/openssl@1.1.1n-0+deb11u1",
+		"lodash":  "pkg:npm/lodash@4.17.20",
+	}
+	got := make(map[string]string)
+	for _, c := range doc.Components {
+		got[c.Name] = c.PURL
+	}
+	for name, purl := range want {
+		if got[name] != purl {
+			t.Errorf("component %q PURL = %q, expected %q", name, got[name], purl)
+		}
+	}
+
+	for _, c := range doc.Components {
+		if c.BOMRef == "" {
+			t.Errorf("component %q has empty BOMRef", c.Name)
+		}
+		if c.PURL != "" && c.BOMRef != c.PURL {
+			t.Errorf("component %q BOMRef = %q, expected PURL %q", c.Name, c.BOMRef, c.PURL)
+		}
+	}
+}
+
+func TestBOMRefFallsBackWithoutPURL(t *testing.T) {
+	c := Component{Name: "widget", Version: "1.0", Source: "vendor/widget.txt"}
+	if got, want := bomRef(c), "widget@1.0:vendor/widget.txt"; got != want {
+		t.Errorf("bomRef() = %q, expected %q", got, want)
+	}
+}
@@ -0,0 +1,95 @@
+package sbom
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// spdxDocument is the subset of the SPDX 2.3 JSON schema
+// (https://spdx.github.io/spdx-spec/v2.3/) WriteSPDX populates.
+type spdxDocument struct {
+	SPDXVersion       string         `json:"spdxVersion"`
+	DataLicense       string         `json:"dataLicense"`
+	SPDXID            string         `json:"SPDXID"`
+	Name              string         `json:"name"`
+	DocumentNamespace string         `json:"documentNamespace"`
+	Packages          []spdxPackage  `json:"packages"`
+	Relationships     []spdxRelation `json:"relationships"`
+}
+
+type spdxPackage struct {
+	SPDXID           string            `json:"SPDXID"`
+	Name             string            `json:"name"`
+	VersionInfo      string            `json:"versionInfo"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	ExternalRefs     []spdxExternalRef `json:"externalRefs,omitempty"`
+	Checksums        []spdxChecksum    `json:"checksums,omitempty"`
+}
+
+type spdxExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type spdxChecksum struct {
+	Algorithm     string `json:"algorithm"`
+	ChecksumValue string `json:"checksumValue"`
+}
+
+type spdxRelation struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// WriteSPDX renders doc as an SPDX 2.3 document in JSON to w. Each
+// component becomes a package DESCRIBED_BY the document root, identified
+// by an SPDXRef derived from its position in doc.Components; its PURL (if
+// any) is recorded as a package-manager externalRef, the same field a tool
+// like Dependency-Track reads to cross-reference a CycloneDX BOM of the
+// same image.
+func WriteSPDX(doc *Document, w io.Writer) error {
+	out := spdxDocument{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              "scallop-sbom",
+		DocumentNamespace: "https://scallop.local/sbom",
+		Packages:          make([]spdxPackage, 0, len(doc.Components)),
+		Relationships:     make([]spdxRelation, 0, len(doc.Components)),
+	}
+
+	for i, c := range doc.Components {
+		id := fmt.Sprintf("SPDXRef-Package-%d", i)
+		pkg := spdxPackage{
+			SPDXID:           id,
+			Name:             c.Name,
+			VersionInfo:      c.Version,
+			DownloadLocation: "NOASSERTION",
+			FilesAnalyzed:    false,
+		}
+		if c.PURL != "" {
+			pkg.ExternalRefs = append(pkg.ExternalRefs, spdxExternalRef{
+				ReferenceCategory: "PACKAGE-MANAGER",
+				ReferenceType:     "purl",
+				ReferenceLocator:  c.PURL,
+			})
+		}
+		for alg, content := range c.Hashes {
+			pkg.Checksums = append(pkg.Checksums, spdxChecksum{Algorithm: alg, ChecksumValue: content})
+		}
+		out.Packages = append(out.Packages, pkg)
+		out.Relationships = append(out.Relationships, spdxRelation{
+			SPDXElementID:      "SPDXRef-DOCUMENT",
+			RelationshipType:   "DESCRIBES",
+			RelatedSPDXElement: id,
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
@@ -0,0 +1,50 @@
+package sbom
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+)
+
+func TestWriteSPDX(t *testing.T) {
+	doc := &Document{Components: []Component{
+		{Name: "lodash", Version: "4.17.21", Ecosystem: "npm", PURL: "pkg:npm/lodash@4.17.21", Source: "package.json"},
+	}}
+
+	var buf bytes.Buffer
+	if err := WriteSPDX(doc, &buf); err != nil {
+		t.Fatalf("WriteSPDX failed: %v", err)
+	}
+
+	var out struct {
+		SPDXVersion string `json:"spdxVersion"`
+		Packages    []struct {
+			SPDXID       string `json:"SPDXID"`
+			Name         string `json:"name"`
+			ExternalRefs []struct {
+				ReferenceType    string `json:"referenceType"`
+				ReferenceLocator string `json:"referenceLocator"`
+			} `json:"externalRefs"`
+		} `json:"packages"`
+		Relationships []struct {
+			RelationshipType   string `json:"relationshipType"`
+			RelatedSPDXElement string `json:"relatedSpdxElement"`
+		} `json:"relationships"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &out); err != nil {
+		t.Fatalf("failed to unmarshal output: %v", err)
+	}
+
+	if out.SPDXVersion != "SPDX-2.3" {
+		t.Errorf("SPDXVersion = %q, expected SPDX-2.3", out.SPDXVersion)
+	}
+	if len(out.Packages) != 1 || out.Packages[0].Name != "lodash" {
+		t.Fatalf("unexpected packages: %+v", out.Packages)
+	}
+	if len(out.Packages[0].ExternalRefs) != 1 || out.Packages[0].ExternalRefs[0].ReferenceLocator != "pkg:npm/lodash@4.17.21" {
+		t.Errorf("unexpected externalRefs: %+v", out.Packages[0].ExternalRefs)
+	}
+	if len(out.Relationships) != 1 || out.Relationships[0].RelatedSPDXElement != out.Packages[0].SPDXID {
+		t.Errorf("unexpected relationships: %+v", out.Relationships)
+	}
+}
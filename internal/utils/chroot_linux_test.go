@@ -0,0 +1,70 @@
+//go:build linux
+
+package utils
+
+import (
+	"archive/tar"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// reexecChrootTestEnv, when set, tells TestExtractTarChrooted it's running
+// as the forked child described below rather than as the top-level test.
+const reexecChrootTestEnv = "SCALLOP_CHROOT_TEST_CHILD"
+
+// TestExtractTarChrooted runs the real extraction in a forked child process
+// rather than in this test binary's own process: ExtractTarChrooted's
+// syscall.Chroot permanently confines whatever process calls it, and this
+// test binary still has other tests to run afterward in the same process.
+func TestExtractTarChrooted(t *testing.T) {
+	tarPath := os.Getenv(reexecChrootTestEnv + "_TAR")
+	destDir := os.Getenv(reexecChrootTestEnv + "_DEST")
+	if os.Getenv(reexecChrootTestEnv) == "1" {
+		if err := ExtractTarChrooted(tarPath, destDir, ExtractOptions{}); err != nil {
+			os.Stderr.WriteString(err.Error())
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+
+	if os.Getuid() != 0 {
+		t.Skip("ExtractTarChrooted requires CAP_SYS_CHROOT (run as root)")
+	}
+
+	tempDir, err := os.MkdirTemp("", "extract-chrooted-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tarPath = filepath.Join(tempDir, "test.tar")
+	writeTar(t, tarPath, []tar.Header{
+		{Name: "file.txt", Typeflag: tar.TypeReg, Mode: 0644},
+	}, map[string]string{"file.txt": "chrooted content"})
+
+	destDir = filepath.Join(tempDir, "dest")
+
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatalf("Failed to resolve test binary path: %v", err)
+	}
+	cmd := exec.Command(self, "-test.run=^TestExtractTarChrooted$")
+	cmd.Env = append(os.Environ(),
+		reexecChrootTestEnv+"=1",
+		reexecChrootTestEnv+"_TAR="+tarPath,
+		reexecChrootTestEnv+"_DEST="+destDir,
+	)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("ExtractTarChrooted child failed: %v: %s", err, out)
+	}
+
+	content, err := os.ReadFile(filepath.Join(destDir, "file.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read extracted file: %v", err)
+	}
+	if string(content) != "chrooted content" {
+		t.Errorf("extracted content = %q, expected %q", content, "chrooted content")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package utils
+
+import "fmt"
+
+// ExtractTarChrooted is only implemented on Linux, where syscall.Chroot is
+// available; see chroot_linux.go. On every other platform it returns an
+// error rather than silently falling back to an unchrooted extraction, so
+// a caller that explicitly asked for chroot-based isolation notices it
+// didn't get it. Use ExtractTarWithOptions directly instead.
+func ExtractTarChrooted(tarPath, destDir string, opts ExtractOptions) error {
+	return fmt.Errorf("ExtractTarChrooted is not supported on this platform; use ExtractTarWithOptions")
+}
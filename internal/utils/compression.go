@@ -0,0 +1,95 @@
+package utils
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/ulikunitz/xz"
+)
+
+// compressionMagic lists the magic bytes DecompressStream and IsArchive
+// recognize, in the same order moby's DetectCompression checks them.
+var compressionMagic = [][]byte{
+	{0x1f, 0x8b},                         // gzip
+	{0x28, 0xb5, 0x2f, 0xfd},             // zstd
+	{0xfd, 0x37, 0x7a, 0x58, 0x5a, 0x00}, // xz
+	{0x42, 0x5a, 0x68},                   // bzip2
+}
+
+// tarMagicOffset and tarMagic locate a raw (uncompressed) tar's "ustar"
+// magic within its 512-byte header, per the POSIX ustar format.
+const tarMagicOffset = 257
+
+var tarMagic = []byte("ustar")
+
+// decompressedStream adapts a decompressor that either has no Close method
+// (bzip2.Reader, xz.Reader) or one with a different signature (zstd.Decoder's
+// Close takes no error) into an io.ReadCloser.
+type decompressedStream struct {
+	io.Reader
+	closeFn func() error
+}
+
+func (d decompressedStream) Close() error {
+	if d.closeFn == nil {
+		return nil
+	}
+	return d.closeFn()
+}
+
+// DecompressStream wraps r in the decompressor matching its leading magic
+// bytes (gzip, zstd, xz, bzip2), or returns it unchanged, as a plain tar
+// stream, if none match. It mirrors Docker's pkg/archive DecompressStream,
+// letting ExtractTar accept a layer blob in whatever compression format it
+// was shipped in rather than only a raw tar.
+func DecompressStream(r io.Reader) (io.ReadCloser, error) {
+	br := bufio.NewReader(r)
+	header, _ := br.Peek(6)
+
+	switch {
+	case bytes.HasPrefix(header, compressionMagic[0]):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open gzip stream: %v", err)
+		}
+		return gz, nil
+	case bytes.HasPrefix(header, compressionMagic[1]):
+		zr, err := zstd.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open zstd stream: %v", err)
+		}
+		return decompressedStream{zr, func() error { zr.Close(); return nil }}, nil
+	case bytes.HasPrefix(header, compressionMagic[2]):
+		xr, err := xz.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open xz stream: %v", err)
+		}
+		return decompressedStream{xr, nil}, nil
+	case bytes.HasPrefix(header, compressionMagic[3]):
+		return decompressedStream{bzip2.NewReader(br), nil}, nil
+	default:
+		return decompressedStream{br, nil}, nil
+	}
+}
+
+// IsArchive reports whether data looks like a (possibly compressed) tar
+// archive: either a raw tar's "ustar" magic at its header's usual offset, or
+// one of the compressed formats DecompressStream recognizes from data's
+// leading bytes. It lets a caller reject a layer blob that isn't an archive
+// at all before spending time extracting it.
+func IsArchive(data []byte) bool {
+	if len(data) >= tarMagicOffset+len(tarMagic) && bytes.Equal(data[tarMagicOffset:tarMagicOffset+len(tarMagic)], tarMagic) {
+		return true
+	}
+	for _, magic := range compressionMagic {
+		if bytes.HasPrefix(data, magic) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package utils
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"testing"
+)
+
+func buildTestTar(t *testing.T) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	w := tar.NewWriter(&buf)
+	content := "hello"
+	if err := w.WriteHeader(&tar.Header{Name: "file.txt", Mode: 0644, Size: int64(len(content)), Typeflag: tar.TypeReg}); err != nil {
+		t.Fatalf("Failed to write tar header: %v", err)
+	}
+	if _, err := w.Write([]byte(content)); err != nil {
+		t.Fatalf("Failed to write tar content: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Failed to close tar writer: %v", err)
+	}
+	return buf.Bytes()
+}
+
+func TestDecompressStreamPlainTar(t *testing.T) {
+	tarBytes := buildTestTar(t)
+
+	rc, err := DecompressStream(bytes.NewReader(tarBytes))
+	if err != nil {
+		t.Fatalf("DecompressStream failed: %v", err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("failed to read decompressed stream: %v", err)
+	}
+	if !bytes.Equal(got, tarBytes) {
+		t.Error("DecompressStream altered a plain (uncompressed) tar stream")
+	}
+}
+
+func TestDecompressStreamGzip(t *testing.T) {
+	tarBytes := buildTestTar(t)
+
+	var gzBuf bytes.Buffer
+	gz := gzip.NewWriter(&gzBuf)
+	if _, err := gz.Write(tarBytes); err != nil {
+		t.Fatalf("failed to gzip tar content: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+
+	rc, err := DecompressStream(&gzBuf)
+	if err != nil {
+		t.Fatalf("DecompressStream failed: %v", err)
+	}
+	defer rc.Close()
+
+	got, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("failed to read decompressed stream: %v", err)
+	}
+	if !bytes.Equal(got, tarBytes) {
+		t.Error("DecompressStream did not correctly decompress a gzip stream")
+	}
+}
+
+func TestIsArchive(t *testing.T) {
+	tarBytes := buildTestTar(t)
+
+	if !IsArchive(tarBytes) {
+		t.Error("IsArchive(plain tar) = false, expected true")
+	}
+
+	var gzBuf bytes.Buffer
+	gz := gzip.NewWriter(&gzBuf)
+	if _, err := gz.Write(tarBytes); err != nil {
+		t.Fatalf("failed to gzip tar content: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("failed to close gzip writer: %v", err)
+	}
+	if !IsArchive(gzBuf.Bytes()) {
+		t.Error("IsArchive(gzipped tar) = false, expected true")
+	}
+
+	if IsArchive([]byte("just some plain text, not an archive")) {
+		t.Error("IsArchive(plain text) = true, expected false")
+	}
+}
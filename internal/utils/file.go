@@ -9,19 +9,79 @@ import (
 	"strings"
 )
 
-// ExtractTar extracts a tar file to the specified directory
+// ExtractOptions bounds the resources ExtractTar will spend on a single tar
+// file, guarding against zip-bomb layers. A zero value means unlimited,
+// which is what ExtractTar uses by default.
+type ExtractOptions struct {
+	// MaxTotalSize caps the sum of all extracted regular file sizes, in
+	// bytes. 0 means unlimited.
+	MaxTotalSize int64
+	// MaxFileCount caps the number of entries (of any type) extracted.
+	// 0 means unlimited.
+	MaxFileCount int
+}
+
+// ExtractTar extracts a tar file to the specified directory, with no
+// MaxTotalSize/MaxFileCount limit. Use ExtractTarWithOptions to bound a
+// tarball of unknown or untrusted origin.
 func ExtractTar(tarPath string, destDir string) error {
-	// Open the tar file
+	return ExtractTarWithOptions(tarPath, destDir, ExtractOptions{})
+}
+
+// ExtractTarWithOptions extracts a tar file to destDir the same way
+// ExtractTar does, auto-decompressing it first via DecompressStream (so a
+// gzip/zstd/xz/bzip2-compressed layer.tar.gz/.zst extracts correctly rather
+// than being read as a raw tar and producing garbage), and hardened against
+// the classic tar-breakout attacks a malicious image layer can attempt:
+//
+//   - every entry's final destination is resolved with secureJoin, which
+//     rejects both ".." traversal and extraction through a path component
+//     that already exists as a symlink (the symlink-then-regular-file race:
+//     a tar entry "etc -> /" followed by "etc/passwd" would otherwise write
+//     through the symlink once the OS resolves it at open(2) time);
+//   - TypeSymlink and TypeLink entries have their Linkname validated against
+//     destDir the same way, so a link can't point (or chain to) anything
+//     outside the extraction root;
+//   - TypeChar, TypeBlock, and TypeFifo entries are skipped entirely - they
+//     have no legitimate use in an extracted image and can only surprise
+//     whatever later code walks the result;
+//   - whiteout entries (.wh.<name>, .wh..wh..opq) remove the path(s) they
+//     mark as deleted instead of being extracted as literal files, so a
+//     destDir shared across layers ends up a correct merged filesystem
+//     rather than silently retaining content a later layer deleted;
+//   - PAX extended headers (e.g. SCHILY.xattr.* records) are left to
+//     archive/tar's own parsing and otherwise ignored: ExtractTar does not
+//     apply xattrs, so there is nothing unsafe to do with them.
+func ExtractTarWithOptions(tarPath string, destDir string, opts ExtractOptions) error {
 	file, err := os.Open(tarPath)
 	if err != nil {
 		return fmt.Errorf("failed to open tar file: %v", err)
 	}
 	defer file.Close()
 
-	// Create a tar reader
-	tarReader := tar.NewReader(file)
+	stream, err := DecompressStream(file)
+	if err != nil {
+		return fmt.Errorf("failed to open tar file: %v", err)
+	}
+	defer stream.Close()
+
+	return ExtractTarReader(tar.NewReader(stream), destDir, opts)
+}
+
+// ExtractTarReader extracts every entry tarReader yields to destDir,
+// applying the same hardening as ExtractTarWithOptions. It is exported
+// separately so a caller that already has a *tar.Reader open over a
+// decompressed stream (e.g. one wrapping a gzip.Reader) doesn't need to
+// write the decompressed bytes back out to a plain tar file first.
+func ExtractTarReader(tarReader *tar.Reader, destDir string, opts ExtractOptions) error {
+	root, err := filepath.Abs(destDir)
+	if err != nil {
+		return fmt.Errorf("failed to resolve destination directory: %v", err)
+	}
+
+	var totalSize int64
+	var fileCount int
 
-	// Extract the tar file
 	for {
 		header, err := tarReader.Next()
 		if err == io.EOF {
@@ -30,50 +90,95 @@ func ExtractTar(tarPath string, destDir string) error {
 		if err != nil {
 			return fmt.Errorf("error reading tar file: %v", err)
 		}
-
-		// Skip if the header is nil
 		if header == nil {
 			continue
 		}
 
-		// Create the file path
-		target := filepath.Join(destDir, header.Name)
+		switch header.Typeflag {
+		case tar.TypeChar, tar.TypeBlock, tar.TypeFifo:
+			// No value in an extracted scan target; skip without even
+			// counting against MaxFileCount.
+			continue
+		}
 
-		// Check for path traversal attacks
-		if !strings.HasPrefix(target, destDir) {
-			return fmt.Errorf("invalid tar file: contains path traversal attack")
+		fileCount++
+		if opts.MaxFileCount > 0 && fileCount > opts.MaxFileCount {
+			return fmt.Errorf("tar file exceeds MaxFileCount (%d)", opts.MaxFileCount)
+		}
+
+		name := filepath.Clean(header.Name)
+		base := filepath.Base(name)
+
+		if base == ".wh..wh..opq" {
+			dir, err := secureJoin(root, filepath.Dir(name))
+			if err != nil {
+				return err
+			}
+			if err := clearDir(dir); err != nil {
+				return fmt.Errorf("failed to apply opaque whiteout for %q: %v", name, err)
+			}
+			continue
+		}
+		if strings.HasPrefix(base, ".wh.") {
+			whited, err := secureJoin(root, filepath.Join(filepath.Dir(name), strings.TrimPrefix(base, ".wh.")))
+			if err != nil {
+				return err
+			}
+			if err := os.RemoveAll(whited); err != nil {
+				return fmt.Errorf("failed to apply whiteout for %q: %v", name, err)
+			}
+			continue
+		}
+
+		target, err := secureJoin(root, name)
+		if err != nil {
+			return err
 		}
 
-		// Handle different types of files
 		switch header.Typeflag {
 		case tar.TypeDir:
-			// Create directory
 			if err := os.MkdirAll(target, 0755); err != nil {
 				return fmt.Errorf("failed to create directory: %v", err)
 			}
 		case tar.TypeReg:
-			// Create directory for the file if it doesn't exist
 			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
 				return fmt.Errorf("failed to create directory: %v", err)
 			}
 
-			// Create the file
-			file, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY, os.FileMode(header.Mode))
+			totalSize += header.Size
+			if opts.MaxTotalSize > 0 && totalSize > opts.MaxTotalSize {
+				return fmt.Errorf("tar file exceeds MaxTotalSize (%d bytes)", opts.MaxTotalSize)
+			}
+
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode))
 			if err != nil {
 				return fmt.Errorf("failed to create file: %v", err)
 			}
-
-			// Copy the file content
-			if _, err := io.Copy(file, tarReader); err != nil {
-				file.Close()
+			if _, err := io.Copy(out, tarReader); err != nil {
+				out.Close()
 				return fmt.Errorf("failed to copy file content: %v", err)
 			}
-			file.Close()
+			out.Close()
 		case tar.TypeSymlink:
-			// Create symlink
+			if err := validateLinkname(root, filepath.Dir(target), header.Linkname); err != nil {
+				return fmt.Errorf("invalid tar entry %q: %v", name, err)
+			}
+			os.Remove(target)
 			if err := os.Symlink(header.Linkname, target); err != nil {
 				return fmt.Errorf("failed to create symlink: %v", err)
 			}
+		case tar.TypeLink:
+			if err := validateLinkname(root, root, header.Linkname); err != nil {
+				return fmt.Errorf("invalid tar entry %q: %v", name, err)
+			}
+			linkTarget, err := secureJoin(root, header.Linkname)
+			if err != nil {
+				return err
+			}
+			os.Remove(target)
+			if err := os.Link(linkTarget, target); err != nil {
+				return fmt.Errorf("failed to create hard link: %v", err)
+			}
 		default:
 			// Skip other types of files
 		}
@@ -82,6 +187,83 @@ func ExtractTar(tarPath string, destDir string) error {
 	return nil
 }
 
+// secureJoin resolves name against root the way ExtractTarWithOptions needs
+// to: it rejects ".." components that would escape root, and it rejects
+// any entry whose path passes through a component that already exists on
+// disk as a symlink, so a tar can't extract a symlink ("etc -> /") and then
+// a regular file ("etc/passwd") that gets written through it once the OS
+// resolves the symlink at open(2) time.
+func secureJoin(root, name string) (string, error) {
+	target := filepath.Join(root, name)
+
+	rel, err := filepath.Rel(root, target)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("invalid tar entry %q: escapes extraction root", name)
+	}
+
+	current := root
+	parts := strings.Split(filepath.ToSlash(rel), "/")
+	for _, part := range parts[:len(parts)-1] {
+		current = filepath.Join(current, part)
+		info, err := os.Lstat(current)
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return "", fmt.Errorf("failed to stat %q: %v", current, err)
+		}
+		if info.Mode()&os.ModeSymlink != 0 {
+			return "", fmt.Errorf("invalid tar entry %q: %q already exists as a symlink, refusing to extract through it", name, part)
+		}
+		if !info.IsDir() {
+			return "", fmt.Errorf("invalid tar entry %q: %q already exists and is not a directory", name, part)
+		}
+	}
+
+	return target, nil
+}
+
+// validateLinkname reports an error if linkname, resolved against base
+// (targetDir for a relative TypeSymlink Linkname, root for an absolute one
+// or a TypeLink Linkname, which always names another archive member), would
+// resolve to a path outside root.
+func validateLinkname(root, base, linkname string) error {
+	if linkname == "" {
+		return nil
+	}
+	resolved := linkname
+	if !filepath.IsAbs(resolved) {
+		resolved = filepath.Join(base, resolved)
+	} else {
+		resolved = filepath.Join(root, resolved)
+	}
+	rel, err := filepath.Rel(root, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("link target %q escapes extraction root", linkname)
+	}
+	return nil
+}
+
+// clearDir removes every entry already present in dir, implementing an
+// AUFS/OverlayFS opaque whiteout (.wh..wh..opq): the directory itself
+// should survive, but whatever an earlier layer put in it should not.
+// A dir that doesn't exist yet has nothing to clear.
+func clearDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // FileExists checks if a file exists
 func FileExists(path string) bool {
 	_, err := os.Stat(path)
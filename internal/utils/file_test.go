@@ -2,6 +2,7 @@ package utils
 
 import (
 	"archive/tar"
+	"compress/gzip"
 	"os"
 	"path/filepath"
 	"testing"
@@ -269,3 +270,267 @@ func TestExtractTar(t *testing.T) {
 		t.Errorf("ExtractTar(%q, %q) should fail with a non-existent tar file", "non-existent-file.tar", destDir)
 	}
 }
+
+// writeTar builds a tar file at path from entries, writing header.Size
+// bytes of content for TypeReg entries.
+func writeTar(t *testing.T, path string, entries []tar.Header, contents map[string]string) {
+	t.Helper()
+
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("Failed to create tar file: %v", err)
+	}
+	defer f.Close()
+
+	tw := tar.NewWriter(f)
+	defer tw.Close()
+
+	for _, header := range entries {
+		content := contents[header.Name]
+		header.Size = int64(len(content))
+		if err := tw.WriteHeader(&header); err != nil {
+			t.Fatalf("Failed to write tar header for %q: %v", header.Name, err)
+		}
+		if content != "" {
+			if _, err := tw.Write([]byte(content)); err != nil {
+				t.Fatalf("Failed to write tar content for %q: %v", header.Name, err)
+			}
+		}
+	}
+}
+
+func TestExtractTarRejectsPathTraversal(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "extract-traversal-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tarPath := filepath.Join(tempDir, "evil.tar")
+	writeTar(t, tarPath, []tar.Header{
+		{Name: "../../etc/passwd", Typeflag: tar.TypeReg, Mode: 0644},
+	}, map[string]string{"../../etc/passwd": "root:x:0:0::/root:/bin/sh"})
+
+	destDir := filepath.Join(tempDir, "dest")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	if err := ExtractTar(tarPath, destDir); err == nil {
+		t.Errorf("ExtractTar should reject a tar entry that traverses outside destDir")
+	}
+	if FileExists(filepath.Join(tempDir, "etc", "passwd")) {
+		t.Errorf("path traversal entry should not have been written outside destDir")
+	}
+}
+
+func TestExtractTarRejectsSymlinkEscape(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "extract-symlink-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tarPath := filepath.Join(tempDir, "evil.tar")
+	writeTar(t, tarPath, []tar.Header{
+		{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "/etc", Mode: 0777},
+		{Name: "link/passwd", Typeflag: tar.TypeReg, Mode: 0644},
+	}, map[string]string{"link/passwd": "pwned"})
+
+	destDir := filepath.Join(tempDir, "dest")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	if err := ExtractTar(tarPath, destDir); err == nil {
+		t.Errorf("ExtractTar should reject an absolute-target symlink escaping destDir")
+	}
+}
+
+func TestExtractTarRejectsWriteThroughExistingSymlink(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "extract-symlink-race-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	outsideDir, err := os.MkdirTemp("", "extract-symlink-outside-")
+	if err != nil {
+		t.Fatalf("Failed to create outside directory: %v", err)
+	}
+	defer os.RemoveAll(outsideDir)
+
+	tarPath := filepath.Join(tempDir, "evil.tar")
+	writeTar(t, tarPath, []tar.Header{
+		{Name: "etc", Typeflag: tar.TypeSymlink, Linkname: outsideDir, Mode: 0777},
+		{Name: "etc/passwd", Typeflag: tar.TypeReg, Mode: 0644},
+	}, map[string]string{"etc/passwd": "pwned"})
+
+	destDir := filepath.Join(tempDir, "dest")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	if err := ExtractTar(tarPath, destDir); err == nil {
+		t.Errorf("ExtractTar should reject extracting through an existing symlink")
+	}
+	if FileExists(filepath.Join(outsideDir, "passwd")) {
+		t.Errorf("ExtractTar must not have written through the symlink into %q", outsideDir)
+	}
+}
+
+func TestExtractTarSkipsDeviceAndFifoEntries(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "extract-devices-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tarPath := filepath.Join(tempDir, "image.tar")
+	writeTar(t, tarPath, []tar.Header{
+		{Name: "null", Typeflag: tar.TypeChar, Mode: 0666, Devmajor: 1, Devminor: 3},
+		{Name: "loop0", Typeflag: tar.TypeBlock, Mode: 0660, Devmajor: 7, Devminor: 0},
+		{Name: "fifo", Typeflag: tar.TypeFifo, Mode: 0644},
+		{Name: "regular.txt", Typeflag: tar.TypeReg, Mode: 0644},
+	}, map[string]string{"regular.txt": "hello"})
+
+	destDir := filepath.Join(tempDir, "dest")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+
+	if err := ExtractTar(tarPath, destDir); err != nil {
+		t.Fatalf("ExtractTar failed: %v", err)
+	}
+
+	for _, skipped := range []string{"null", "loop0", "fifo"} {
+		if FileExists(filepath.Join(destDir, skipped)) {
+			t.Errorf("expected %q to be skipped, but it was extracted", skipped)
+		}
+	}
+	if !FileExists(filepath.Join(destDir, "regular.txt")) {
+		t.Errorf("expected regular.txt to be extracted")
+	}
+}
+
+func TestExtractTarHonorsWhiteouts(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "extract-whiteout-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	destDir := filepath.Join(tempDir, "dest")
+	if err := os.MkdirAll(filepath.Join(destDir, "config"), 0755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(destDir, "config", "secret.txt"), []byte("shh"), 0644); err != nil {
+		t.Fatalf("Failed to seed a pre-existing file: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(destDir, "keep.txt"), []byte("still here"), 0644); err != nil {
+		t.Fatalf("Failed to seed a pre-existing file: %v", err)
+	}
+
+	tarPath := filepath.Join(tempDir, "layer.tar")
+	writeTar(t, tarPath, []tar.Header{
+		{Name: ".wh.keep.txt", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "config/.wh..wh..opq", Typeflag: tar.TypeReg, Mode: 0644},
+	}, nil)
+
+	if err := ExtractTar(tarPath, destDir); err != nil {
+		t.Fatalf("ExtractTar failed: %v", err)
+	}
+
+	if FileExists(filepath.Join(destDir, "keep.txt")) {
+		t.Errorf("expected .wh.keep.txt to remove keep.txt")
+	}
+	if FileExists(filepath.Join(destDir, ".wh.keep.txt")) {
+		t.Errorf("the whiteout marker itself should not be left behind")
+	}
+	if FileExists(filepath.Join(destDir, "config", "secret.txt")) {
+		t.Errorf("expected the opaque whiteout to clear config/secret.txt")
+	}
+	if !DirExists(filepath.Join(destDir, "config")) {
+		t.Errorf("the opaque whiteout should clear config's contents, not config itself")
+	}
+}
+
+func TestExtractTarWithOptionsEnforcesLimits(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "extract-limits-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	tarPath := filepath.Join(tempDir, "bomb.tar")
+	writeTar(t, tarPath, []tar.Header{
+		{Name: "a.txt", Typeflag: tar.TypeReg, Mode: 0644},
+		{Name: "b.txt", Typeflag: tar.TypeReg, Mode: 0644},
+	}, map[string]string{"a.txt": "0123456789", "b.txt": "0123456789"})
+
+	destDir := filepath.Join(tempDir, "dest-size")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+	if err := ExtractTarWithOptions(tarPath, destDir, ExtractOptions{MaxTotalSize: 15}); err == nil {
+		t.Errorf("expected MaxTotalSize to be enforced")
+	}
+
+	destDir2 := filepath.Join(tempDir, "dest-count")
+	if err := os.MkdirAll(destDir2, 0755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+	if err := ExtractTarWithOptions(tarPath, destDir2, ExtractOptions{MaxFileCount: 1}); err == nil {
+		t.Errorf("expected MaxFileCount to be enforced")
+	}
+}
+
+func TestExtractTarGzipCompressed(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "extract-gzip-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	rawPath := filepath.Join(tempDir, "raw.tar")
+	writeTar(t, rawPath, []tar.Header{
+		{Name: "file.txt", Typeflag: tar.TypeReg, Mode: 0644},
+	}, map[string]string{"file.txt": "compressed layer content"})
+
+	raw, err := os.ReadFile(rawPath)
+	if err != nil {
+		t.Fatalf("Failed to read raw tar: %v", err)
+	}
+
+	tarPath := filepath.Join(tempDir, "layer.tar.gz")
+	gzFile, err := os.Create(tarPath)
+	if err != nil {
+		t.Fatalf("Failed to create gzip tar file: %v", err)
+	}
+	gz := gzip.NewWriter(gzFile)
+	if _, err := gz.Write(raw); err != nil {
+		t.Fatalf("Failed to write gzip content: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("Failed to close gzip writer: %v", err)
+	}
+	if err := gzFile.Close(); err != nil {
+		t.Fatalf("Failed to close gzip tar file: %v", err)
+	}
+
+	destDir := filepath.Join(tempDir, "extracted")
+	if err := os.MkdirAll(destDir, 0755); err != nil {
+		t.Fatalf("Failed to create destination directory: %v", err)
+	}
+	if err := ExtractTarWithOptions(tarPath, destDir, ExtractOptions{}); err != nil {
+		t.Fatalf("ExtractTarWithOptions failed on a gzip-compressed tar: %v", err)
+	}
+
+	content, err := os.ReadFile(filepath.Join(destDir, "file.txt"))
+	if err != nil {
+		t.Fatalf("Failed to read extracted file: %v", err)
+	}
+	if string(content) != "compressed layer content" {
+		t.Errorf("extracted content = %q, expected %q", content, "compressed layer content")
+	}
+}
@@ -5,50 +5,84 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
+
+	multierror "github.com/hashicorp/go-multierror"
+	yaml "gopkg.in/yaml.v3"
 )
 
 // Config represents the configuration for the scallop tool
 type Config struct {
 	// Default output format (text or json)
-	DefaultOutputFormat string `json:"defaultOutputFormat"`
+	DefaultOutputFormat string `json:"defaultOutputFormat" yaml:"defaultOutputFormat"`
 
 	// Default verbosity level
-	Verbose bool `json:"verbose"`
+	Verbose bool `json:"verbose" yaml:"verbose"`
 
 	// Security scan settings
-	Security SecurityConfig `json:"security"`
+	Security SecurityConfig `json:"security" yaml:"security"`
 
 	// Size analysis settings
-	Size SizeConfig `json:"size"`
+	Size SizeConfig `json:"size" yaml:"size"`
+
+	// Number of goroutines used to walk subdirectories in parallel.
+	// A value <= 0 means "use runtime.NumCPU()".
+	Concurrency int `json:"concurrency" yaml:"concurrency"`
 }
 
 // SecurityConfig represents the security scan configuration
 type SecurityConfig struct {
 	// Enable security scanning
-	Enabled bool `json:"enabled"`
+	Enabled bool `json:"enabled" yaml:"enabled"`
 
 	// Minimum severity level to report (LOW, MEDIUM, HIGH)
-	MinSeverity string `json:"minSeverity"`
+	MinSeverity string `json:"minSeverity" yaml:"minSeverity"`
 
 	// Custom patterns for sensitive files
-	SensitivePatterns []string `json:"sensitivePatterns"`
+	SensitivePatterns []string `json:"sensitivePatterns" yaml:"sensitivePatterns"`
 
 	// Custom patterns for hardcoded secrets
-	SecretPatterns []string `json:"secretPatterns"`
+	SecretPatterns []string `json:"secretPatterns" yaml:"secretPatterns"`
+
+	// MaxExtractSize caps the sum of all regular file bytes scallop will
+	// extract from an image tarball, guarding against a decompression-bomb
+	// layer. 0 means unlimited. A caller passes this through as
+	// utils.ExtractOptions.MaxTotalSize (see docker.ExtractImageWithOptions).
+	MaxExtractSize int64 `json:"maxExtractSize" yaml:"maxExtractSize"`
+
+	// MaxExtractFileCount caps the number of tar entries scallop will
+	// extract from an image tarball. 0 means unlimited. A caller passes this
+	// through as utils.ExtractOptions.MaxFileCount.
+	MaxExtractFileCount int `json:"maxExtractFileCount" yaml:"maxExtractFileCount"`
+
+	// VerifyDigests makes size analysis fail when a layer's compressed blob
+	// or decompressed content doesn't match the digest its manifest or
+	// image config lists, instead of only recording a best-effort
+	// IntegrityError. Off by default since it hashes every layer twice. A
+	// caller passes this through as analyzer.WithVerifyDigests, e.g. to let
+	// a CI pipeline fail the build on a tampered or corrupted image.
+	VerifyDigests bool `json:"verifyDigests" yaml:"verifyDigests"`
 }
 
 // SizeConfig represents the size analysis configuration
 type SizeConfig struct {
 	// Enable size analysis
-	Enabled bool `json:"enabled"`
+	Enabled bool `json:"enabled" yaml:"enabled"`
 
 	// Number of largest files to report
-	TopFilesCount int `json:"topFilesCount"`
+	TopFilesCount int `json:"topFilesCount" yaml:"topFilesCount"`
 
 	// Number of largest directories to report
-	TopDirsCount int `json:"topDirsCount"`
+	TopDirsCount int `json:"topDirsCount" yaml:"topDirsCount"`
 }
 
+// validSeverities are the recognized values for Security.MinSeverity.
+var validSeverities = map[string]bool{"LOW": true, "MEDIUM": true, "HIGH": true}
+
+// validOutputFormats are the recognized values for DefaultOutputFormat.
+var validOutputFormats = map[string]bool{"text": true, "json": true, "sarif": true, "junit": true}
+
 // DefaultConfig returns the default configuration
 func DefaultConfig() *Config {
 	return &Config{
@@ -66,7 +100,62 @@ func DefaultConfig() *Config {
 	}
 }
 
-// LoadConfig loads the configuration from a file
+// Validate checks the configuration for invalid values, returning every
+// problem found (via a multierror) rather than just the first.
+func (c *Config) Validate() error {
+	var result *multierror.Error
+
+	if !validSeverities[c.Security.MinSeverity] {
+		result = multierror.Append(result, fmt.Errorf("security.minSeverity: invalid value %q, must be one of LOW, MEDIUM, HIGH", c.Security.MinSeverity))
+	}
+
+	if !validOutputFormats[c.DefaultOutputFormat] {
+		result = multierror.Append(result, fmt.Errorf("defaultOutputFormat: invalid value %q, must be one of text, json, sarif, junit", c.DefaultOutputFormat))
+	}
+
+	if c.Size.TopFilesCount < 0 {
+		result = multierror.Append(result, fmt.Errorf("size.topFilesCount: must not be negative, got %d", c.Size.TopFilesCount))
+	}
+
+	if c.Size.TopDirsCount < 0 {
+		result = multierror.Append(result, fmt.Errorf("size.topDirsCount: must not be negative, got %d", c.Size.TopDirsCount))
+	}
+
+	if c.Security.MaxExtractSize < 0 {
+		result = multierror.Append(result, fmt.Errorf("security.maxExtractSize: must not be negative, got %d", c.Security.MaxExtractSize))
+	}
+
+	if c.Security.MaxExtractFileCount < 0 {
+		result = multierror.Append(result, fmt.Errorf("security.maxExtractFileCount: must not be negative, got %d", c.Security.MaxExtractFileCount))
+	}
+
+	return result.ErrorOrNil()
+}
+
+// unmarshalConfig parses data into cfg, dispatching on the file extension:
+// ".yaml"/".yml" are parsed as YAML, everything else as JSON.
+func unmarshalConfig(path string, data []byte, cfg *Config) error {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return yaml.Unmarshal(data, cfg)
+	default:
+		return json.Unmarshal(data, cfg)
+	}
+}
+
+// marshalConfig serializes cfg, dispatching on the file extension:
+// ".yaml"/".yml" are written as YAML, everything else as JSON.
+func marshalConfig(path string, cfg *Config) ([]byte, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		return yaml.Marshal(cfg)
+	default:
+		return json.MarshalIndent(cfg, "", "  ")
+	}
+}
+
+// LoadConfig loads the configuration from a file. The format (JSON or YAML)
+// is determined by the file extension.
 func LoadConfig(path string) (*Config, error) {
 	// Use default config if no path is provided
 	if path == "" {
@@ -81,14 +170,15 @@ func LoadConfig(path string) (*Config, error) {
 
 	// Parse the config file
 	config := DefaultConfig()
-	if err := json.Unmarshal(data, config); err != nil {
+	if err := unmarshalConfig(path, data, config); err != nil {
 		return nil, fmt.Errorf("failed to parse config file: %v", err)
 	}
 
 	return config, nil
 }
 
-// SaveConfig saves the configuration to a file
+// SaveConfig saves the configuration to a file. The format (JSON or YAML)
+// is determined by the file extension.
 func SaveConfig(config *Config, path string) error {
 	// Create the directory if it doesn't exist
 	dir := filepath.Dir(path)
@@ -96,8 +186,8 @@ func SaveConfig(config *Config, path string) error {
 		return fmt.Errorf("failed to create directory: %v", err)
 	}
 
-	// Marshal the config to JSON
-	data, err := json.MarshalIndent(config, "", "  ")
+	// Marshal the config
+	data, err := marshalConfig(path, config)
 	if err != nil {
 		return fmt.Errorf("failed to marshal config: %v", err)
 	}
@@ -129,3 +219,107 @@ func GetConfigPath() string {
 	// Return the default path
 	return ""
 }
+
+// Overrides holds optionally-set configuration values, used to layer CLI
+// flags and environment variables on top of file-based configuration.
+// Unset fields (nil pointers, nil slices) are left untouched by the layer
+// they are applied from.
+type Overrides struct {
+	DefaultOutputFormat *string
+	Verbose             *bool
+	SecurityEnabled     *bool
+	MinSeverity         *string
+	TopFilesCount       *int
+	TopDirsCount        *int
+}
+
+// apply merges the set fields of o into cfg, leaving unset fields unchanged.
+func (o Overrides) apply(cfg *Config) {
+	if o.DefaultOutputFormat != nil {
+		cfg.DefaultOutputFormat = *o.DefaultOutputFormat
+	}
+	if o.Verbose != nil {
+		cfg.Verbose = *o.Verbose
+	}
+	if o.SecurityEnabled != nil {
+		cfg.Security.Enabled = *o.SecurityEnabled
+	}
+	if o.MinSeverity != nil {
+		cfg.Security.MinSeverity = *o.MinSeverity
+	}
+	if o.TopFilesCount != nil {
+		cfg.Size.TopFilesCount = *o.TopFilesCount
+	}
+	if o.TopDirsCount != nil {
+		cfg.Size.TopDirsCount = *o.TopDirsCount
+	}
+}
+
+// overridesFromEnv reads SCALLOP_* environment variables into an Overrides
+// value. Variables that aren't set, or that fail to parse, are left nil.
+func overridesFromEnv() Overrides {
+	var o Overrides
+
+	if v, ok := os.LookupEnv("SCALLOP_OUTPUT_FORMAT"); ok {
+		o.DefaultOutputFormat = &v
+	}
+	if v, ok := os.LookupEnv("SCALLOP_VERBOSE"); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			o.Verbose = &b
+		}
+	}
+	if v, ok := os.LookupEnv("SCALLOP_SECURITY_ENABLED"); ok {
+		if b, err := strconv.ParseBool(v); err == nil {
+			o.SecurityEnabled = &b
+		}
+	}
+	if v, ok := os.LookupEnv("SCALLOP_MIN_SEVERITY"); ok {
+		o.MinSeverity = &v
+	}
+	if v, ok := os.LookupEnv("SCALLOP_TOP_FILES_COUNT"); ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			o.TopFilesCount = &n
+		}
+	}
+	if v, ok := os.LookupEnv("SCALLOP_TOP_DIRS_COUNT"); ok {
+		if n, err := strconv.Atoi(v); err == nil {
+			o.TopDirsCount = &n
+		}
+	}
+
+	return o
+}
+
+// ResolveConfig builds the effective configuration by layering, in order:
+// built-in defaults, scallop.json in the current directory,
+// ~/.config/scallop/config.json, SCALLOP_* environment variables, and
+// finally flagOverrides (CLI flags, which take precedence over everything
+// else). Each layer only overrides the fields it explicitly sets. The
+// result is validated before being returned.
+func ResolveConfig(flagOverrides Overrides) (*Config, error) {
+	cfg := DefaultConfig()
+
+	if data, err := os.ReadFile("scallop.json"); err == nil {
+		if err := unmarshalConfig("scallop.json", data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse scallop.json: %v", err)
+		}
+	}
+
+	if home, err := os.UserHomeDir(); err == nil {
+		path := filepath.Join(home, ".config", "scallop", "config.json")
+		if data, err := os.ReadFile(path); err == nil {
+			if err := unmarshalConfig(path, data, cfg); err != nil {
+				return nil, fmt.Errorf("failed to parse %s: %v", path, err)
+			}
+		}
+	}
+
+	overridesFromEnv().apply(cfg)
+	flagOverrides.apply(cfg)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
@@ -5,6 +5,8 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+
+	multierror "github.com/hashicorp/go-multierror"
 )
 
 func TestDefaultConfig(t *testing.T) {
@@ -249,3 +251,107 @@ func TestGetConfigPath(t *testing.T) {
 	// Note: This is a simplified test that doesn't actually create a file in the user's home directory
 	// In a real test, you would mock the os.UserHomeDir function or create a temporary home directory
 }
+
+func TestValidate(t *testing.T) {
+	// A default config should validate cleanly
+	if err := DefaultConfig().Validate(); err != nil {
+		t.Errorf("DefaultConfig().Validate() = %v, expected nil", err)
+	}
+
+	cfg := &Config{
+		DefaultOutputFormat: "bogus",
+		Security:            SecurityConfig{MinSeverity: "EXTREME", MaxExtractSize: -1, MaxExtractFileCount: -1},
+		Size:                SizeConfig{TopFilesCount: -1, TopDirsCount: -5},
+	}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatalf("Validate should fail for an invalid config")
+	}
+
+	merr, ok := err.(*multierror.Error)
+	if !ok {
+		t.Fatalf("Validate error = %T, expected *multierror.Error", err)
+	}
+	if len(merr.Errors) != 6 {
+		t.Errorf("len(merr.Errors) = %d, expected 6 (one per invalid field)", len(merr.Errors))
+	}
+}
+
+func TestLoadConfigYAML(t *testing.T) {
+	tempDir, err := os.MkdirTemp("", "config-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+
+	configPath := filepath.Join(tempDir, "config.yaml")
+	testConfig := &Config{
+		DefaultOutputFormat: "json",
+		Verbose:             true,
+		Security:            SecurityConfig{Enabled: true, MinSeverity: "HIGH"},
+		Size:                SizeConfig{Enabled: true, TopFilesCount: 15, TopDirsCount: 3},
+	}
+
+	if err := SaveConfig(testConfig, configPath); err != nil {
+		t.Fatalf("SaveConfig failed: %v", err)
+	}
+
+	loadedConfig, err := LoadConfig(configPath)
+	if err != nil {
+		t.Fatalf("LoadConfig failed: %v", err)
+	}
+
+	if loadedConfig.DefaultOutputFormat != testConfig.DefaultOutputFormat {
+		t.Errorf("DefaultOutputFormat = %q, expected %q", loadedConfig.DefaultOutputFormat, testConfig.DefaultOutputFormat)
+	}
+	if loadedConfig.Security.MinSeverity != testConfig.Security.MinSeverity {
+		t.Errorf("Security.MinSeverity = %q, expected %q", loadedConfig.Security.MinSeverity, testConfig.Security.MinSeverity)
+	}
+	if loadedConfig.Size.TopFilesCount != testConfig.Size.TopFilesCount {
+		t.Errorf("Size.TopFilesCount = %d, expected %d", loadedConfig.Size.TopFilesCount, testConfig.Size.TopFilesCount)
+	}
+}
+
+func TestResolveConfigWithEnvAndFlagOverrides(t *testing.T) {
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Failed to get current working directory: %v", err)
+	}
+	tempDir, err := os.MkdirTemp("", "config-test-")
+	if err != nil {
+		t.Fatalf("Failed to create temporary directory: %v", err)
+	}
+	defer os.RemoveAll(tempDir)
+	if err := os.Chdir(tempDir); err != nil {
+		t.Fatalf("Failed to change to temporary directory: %v", err)
+	}
+	defer os.Chdir(cwd)
+
+	// scallop.json in the cwd sets MinSeverity
+	if err := os.WriteFile("scallop.json", []byte(`{"security":{"minSeverity":"MEDIUM"}}`), 0644); err != nil {
+		t.Fatalf("Failed to write scallop.json: %v", err)
+	}
+
+	// The env var should override the file
+	os.Setenv("SCALLOP_MIN_SEVERITY", "HIGH")
+	defer os.Unsetenv("SCALLOP_MIN_SEVERITY")
+
+	// A flag override should take precedence over both
+	topFiles := 42
+	cfg, err := ResolveConfig(Overrides{TopFilesCount: &topFiles})
+	if err != nil {
+		t.Fatalf("ResolveConfig failed: %v", err)
+	}
+
+	if cfg.Security.MinSeverity != "HIGH" {
+		t.Errorf("Security.MinSeverity = %q, expected %q (env should win over file)", cfg.Security.MinSeverity, "HIGH")
+	}
+	if cfg.Size.TopFilesCount != topFiles {
+		t.Errorf("Size.TopFilesCount = %d, expected %d (flag override)", cfg.Size.TopFilesCount, topFiles)
+	}
+	// Untouched fields should still carry their defaults
+	if cfg.DefaultOutputFormat != "text" {
+		t.Errorf("DefaultOutputFormat = %q, expected default %q", cfg.DefaultOutputFormat, "text")
+	}
+}